@@ -448,7 +448,7 @@ func unbindMap(output map[string]string, name string, iface interface{}) {
 // from one or more values from Params.
 // Returns the zero value of the type upon any sort of failure.
 func Bind(params *web.Params, name string, typ reflect.Type) reflect.Value {
-	if binder, found := binderForType(typ); found {
+	if binder, found := BinderFor(typ); found {
 		return binder.Bind(params, name, typ)
 	}
 	return reflect.Zero(typ)
@@ -463,14 +463,16 @@ func BindFile(fileHeader *multipart.FileHeader, typ reflect.Type) reflect.Value
 }
 
 func Unbind(output map[string]string, name string, val interface{}) {
-	if binder, found := binderForType(reflect.TypeOf(val)); found {
+	if binder, found := BinderFor(reflect.TypeOf(val)); found {
 		if binder.Unbind != nil {
 			binder.Unbind(output, name, val)
 		}
 	}
 }
 
-func binderForType(typ reflect.Type) (Binder, bool) {
+// BinderFor finds the binder of the type, the type binder before the kind binder.
+// Resolve it once to bind the same type many times.
+func BinderFor(typ reflect.Type) (Binder, bool) {
 	binder, ok := TypeBinders[typ]
 	if !ok {
 		binder, ok = KindBinders[typ.Kind()]
@@ -3,6 +3,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"github.com/roverli/light/log"
 	"github.com/roverli/utils/errors"
@@ -23,7 +24,7 @@ const (
 	UnkownErr                     // Unkown reason error.
 )
 
-func selectRaw(id string, pvalue interface{}, executor Executor) (data []interface{}, e errors.Error) {
+func selectRaw(ctx context.Context, id string, pvalue interface{}, executor Executor) (data []interface{}, e errors.Error) {
 	defer func() {
 		if err := recover(); err != nil {
 			log.Errorf("light/db: Panic error. Err: %v", err)
@@ -38,7 +39,7 @@ func selectRaw(id string, pvalue interface{}, executor Executor) (data []interfa
 	}
 
 	sql, params := st.ProcParam(pvalue)
-	preStatement, err := executor.prepare(sql)
+	preStatement, err := executor.prepare(ctx, sql)
 	log.Debugf("light/db: Exec statement: %s. SQL:%s. Params: %v.", id, sql, params)
 
 	if err != nil {
@@ -46,7 +47,7 @@ func selectRaw(id string, pvalue interface{}, executor Executor) (data []interfa
 	}
 
 	defer preStatement.Close()
-	rows, err := preStatement.Query(params...)
+	rows, err := preStatement.QueryContext(ctx, params...)
 
 	if err != nil {
 		return nil, errors.WrapfByCode(QueryErr, err, "light/db: Query Err. SQL: %s. Params: %v. Statement: %s.", sql, params, id)
@@ -80,7 +81,7 @@ func selectRaw(id string, pvalue interface{}, executor Executor) (data []interfa
 	return result, nil
 }
 
-func rawExec(op Operation, id string, pValue interface{}, executor Executor) (data sql.Result, e errors.Error) {
+func rawExec(ctx context.Context, op Operation, id string, pValue interface{}, executor Executor) (data sql.Result, e errors.Error) {
 	defer func() {
 		if err := recover(); err != nil {
 			log.Errorf("light/db: Panic error. Err: %v", err)
@@ -99,25 +100,25 @@ func rawExec(op Operation, id string, pValue interface{}, executor Executor) (da
 	}
 
 	sql, params := statement.ProcParam(pValue)
-	preStatement, err := executor.prepare(sql)
+	preStatement, err := executor.prepare(ctx, sql)
 	log.Debugf("light/db: Exec statement: %s. SQL:%s. Params: %v.", id, sql, params)
 
 	if err != nil {
-		return nil, errors.WrapfByCode(PreSQLErr, e, "light/db: Prepare SQL error. SQL: %s. Statement: %s.", sql, id)
+		return nil, errors.WrapfByCode(PreSQLErr, err, "light/db: Prepare SQL error. SQL: %s. Statement: %s.", sql, id)
 	}
 
 	defer preStatement.Close()
 
-	result, err := preStatement.Exec(params...)
+	result, err := preStatement.ExecContext(ctx, params...)
 	if err != nil {
-		return nil, errors.WrapfByCode(ExecErr, e, "light/db: Exec Err. SQL: %s. Params: %v. Statement: %s.", sql, params, id)
+		return nil, errors.WrapfByCode(ExecErr, err, "light/db: Exec Err. SQL: %s. Params: %v. Statement: %s.", sql, params, id)
 	}
 
 	return result, nil
 }
 
-func queryOne(id string, param interface{}, executor Executor) (interface{}, errors.Error) {
-	results, err := selectRaw(id, param, executor)
+func queryOne(ctx context.Context, id string, param interface{}, executor Executor) (interface{}, errors.Error) {
+	results, err := selectRaw(ctx, id, param, executor)
 	if err != nil {
 		return nil, err
 	}
@@ -132,12 +133,12 @@ func queryOne(id string, param interface{}, executor Executor) (interface{}, err
 	}
 }
 
-func queryMany(id string, param interface{}, executor Executor) ([]interface{}, errors.Error) {
-	return selectRaw(id, param, executor)
+func queryMany(ctx context.Context, id string, param interface{}, executor Executor) ([]interface{}, errors.Error) {
+	return selectRaw(ctx, id, param, executor)
 }
 
-func insert(id string, param interface{}, executor Executor) (int64, errors.Error) {
-	result, err1 := rawExec(INSERT, id, param, executor)
+func insert(ctx context.Context, id string, param interface{}, executor Executor) (int64, errors.Error) {
+	result, err1 := rawExec(ctx, INSERT, id, param, executor)
 	if err1 != nil {
 		return 0, err1
 	}
@@ -149,8 +150,8 @@ func insert(id string, param interface{}, executor Executor) (int64, errors.Erro
 	return insertId, nil
 }
 
-func execWithAffectedRows(op Operation, id string, param interface{}, executor Executor) (int64, errors.Error) {
-	result, err1 := rawExec(op, id, param, executor)
+func execWithAffectedRows(ctx context.Context, op Operation, id string, param interface{}, executor Executor) (int64, errors.Error) {
+	result, err1 := rawExec(ctx, op, id, param, executor)
 	if err1 != nil {
 		return 0, err1
 	}
@@ -163,14 +164,14 @@ func execWithAffectedRows(op Operation, id string, param interface{}, executor E
 
 }
 
-func update(id string, param interface{}, executor Executor) (int64, errors.Error) {
-	return execWithAffectedRows(UPDATE, id, param, executor)
+func update(ctx context.Context, id string, param interface{}, executor Executor) (int64, errors.Error) {
+	return execWithAffectedRows(ctx, UPDATE, id, param, executor)
 }
 
-func delete(id string, param interface{}, executor Executor) (int64, errors.Error) {
-	return execWithAffectedRows(DELETE, id, param, executor)
+func delete(ctx context.Context, id string, param interface{}, executor Executor) (int64, errors.Error) {
+	return execWithAffectedRows(ctx, DELETE, id, param, executor)
 }
 
-func exec(id string, param interface{}, executor Executor) (sql.Result, errors.Error) {
-	return rawExec(UNKOWN, id, param, executor)
+func exec(ctx context.Context, id string, param interface{}, executor Executor) (sql.Result, errors.Error) {
+	return rawExec(ctx, UNKOWN, id, param, executor)
 }
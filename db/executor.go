@@ -3,6 +3,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"github.com/roverli/utils/errors"
 )
@@ -52,10 +53,19 @@ type Executor interface {
 	// data for the WHERE clause or supply the input data.
 	Exec(id string, param interface{}) (sql.Result, errors.Error)
 
+	// The variants with the context, the statement is cancelled when the context is done,
+	// such as the request context timed out.
+	QueryOneContext(ctx context.Context, id string, param interface{}) (interface{}, errors.Error)
+	QueryManyContext(ctx context.Context, id string, param interface{}) ([]interface{}, errors.Error)
+	InsertContext(ctx context.Context, id string, param interface{}) (int64, errors.Error)
+	UpdateContext(ctx context.Context, id string, param interface{}) (int64, errors.Error)
+	DeleteContext(ctx context.Context, id string, param interface{}) (int64, errors.Error)
+	ExecContext(ctx context.Context, id string, param interface{}) (sql.Result, errors.Error)
+
 	/** Inner methods. **/
 
 	// Prepare a sql stament
-	prepare(sql string) (*sql.Stmt, error)
+	prepare(ctx context.Context, sql string) (*sql.Stmt, error)
 
 	// Retrieve a sql statement definition.
 	statement(id string) *Statement
@@ -81,31 +91,55 @@ func (db *DB) Dynamicer(name string, dynamicer Dynamicer) {
 }
 
 func (db *DB) QueryOne(id string, param interface{}) (interface{}, errors.Error) {
-	return queryOne(id, param, db)
+	return queryOne(context.Background(), id, param, db)
+}
+
+func (db *DB) QueryOneContext(ctx context.Context, id string, param interface{}) (interface{}, errors.Error) {
+	return queryOne(ctx, id, param, db)
 }
 
 func (db *DB) QueryMany(id string, param interface{}) ([]interface{}, errors.Error) {
-	return queryMany(id, param, db)
+	return queryMany(context.Background(), id, param, db)
+}
+
+func (db *DB) QueryManyContext(ctx context.Context, id string, param interface{}) ([]interface{}, errors.Error) {
+	return queryMany(ctx, id, param, db)
 }
 
 func (db *DB) Insert(id string, param interface{}) (int64, errors.Error) {
-	return insert(id, param, db)
+	return insert(context.Background(), id, param, db)
+}
+
+func (db *DB) InsertContext(ctx context.Context, id string, param interface{}) (int64, errors.Error) {
+	return insert(ctx, id, param, db)
 }
 
 func (db *DB) Update(id string, param interface{}) (int64, errors.Error) {
-	return update(id, param, db)
+	return update(context.Background(), id, param, db)
+}
+
+func (db *DB) UpdateContext(ctx context.Context, id string, param interface{}) (int64, errors.Error) {
+	return update(ctx, id, param, db)
 }
 
 func (db *DB) Delete(id string, param interface{}) (int64, errors.Error) {
-	return delete(id, param, db)
+	return delete(context.Background(), id, param, db)
+}
+
+func (db *DB) DeleteContext(ctx context.Context, id string, param interface{}) (int64, errors.Error) {
+	return delete(ctx, id, param, db)
 }
 
 func (db *DB) Exec(id string, param interface{}) (sql.Result, errors.Error) {
-	return exec(id, param, db)
+	return exec(context.Background(), id, param, db)
 }
 
-func (db *DB) prepare(sql string) (*sql.Stmt, error) {
-	return db.db.Prepare(sql)
+func (db *DB) ExecContext(ctx context.Context, id string, param interface{}) (sql.Result, errors.Error) {
+	return exec(ctx, id, param, db)
+}
+
+func (db *DB) prepare(ctx context.Context, sql string) (*sql.Stmt, error) {
+	return db.db.PrepareContext(ctx, sql)
 }
 
 func (db *DB) statement(id string) *Statement {
@@ -127,9 +161,16 @@ type TxCallBack interface {
 
 // Execute a transaction with callback.
 // If callBack method panic, tx will auto rollback
-func (db *DB) DoTransaction(cb TxCallBack) (err errors.Error) {
+func (db *DB) DoTransaction(cb TxCallBack) errors.Error {
+	return db.DoTransactionContext(context.Background(), nil, cb)
+}
 
-	tx, e := db.db.Begin()
+// Execute a transaction with callback, begun with the context and options.
+// The transaction is rolled back when the context is done before it commits,
+// and the commit fails then. Statements in the callback should use the same context.
+func (db *DB) DoTransactionContext(ctx context.Context, opts *sql.TxOptions, cb TxCallBack) (err errors.Error) {
+
+	tx, e := db.db.BeginTx(ctx, opts)
 	if e != nil {
 		err = errors.Wrap(e, "light/db: cann't begin a transaction.")
 		return
@@ -155,31 +196,55 @@ func (db *DB) DoTransaction(cb TxCallBack) (err errors.Error) {
 }
 
 func (transaction *Transaction) QueryOne(id string, param interface{}) (interface{}, errors.Error) {
-	return queryOne(id, param, transaction)
+	return queryOne(context.Background(), id, param, transaction)
+}
+
+func (transaction *Transaction) QueryOneContext(ctx context.Context, id string, param interface{}) (interface{}, errors.Error) {
+	return queryOne(ctx, id, param, transaction)
 }
 
 func (transaction *Transaction) QueryMany(id string, param interface{}) ([]interface{}, errors.Error) {
-	return queryMany(id, param, transaction)
+	return queryMany(context.Background(), id, param, transaction)
+}
+
+func (transaction *Transaction) QueryManyContext(ctx context.Context, id string, param interface{}) ([]interface{}, errors.Error) {
+	return queryMany(ctx, id, param, transaction)
 }
 
 func (transaction *Transaction) Insert(id string, param interface{}) (int64, errors.Error) {
-	return insert(id, param, transaction)
+	return insert(context.Background(), id, param, transaction)
+}
+
+func (transaction *Transaction) InsertContext(ctx context.Context, id string, param interface{}) (int64, errors.Error) {
+	return insert(ctx, id, param, transaction)
 }
 
 func (transaction *Transaction) Update(id string, param interface{}) (int64, errors.Error) {
-	return update(id, param, transaction)
+	return update(context.Background(), id, param, transaction)
+}
+
+func (transaction *Transaction) UpdateContext(ctx context.Context, id string, param interface{}) (int64, errors.Error) {
+	return update(ctx, id, param, transaction)
 }
 
 func (transaction *Transaction) Delete(id string, param interface{}) (int64, errors.Error) {
-	return delete(id, param, transaction)
+	return delete(context.Background(), id, param, transaction)
+}
+
+func (transaction *Transaction) DeleteContext(ctx context.Context, id string, param interface{}) (int64, errors.Error) {
+	return delete(ctx, id, param, transaction)
 }
 
 func (transaction *Transaction) Exec(id string, param interface{}) (sql.Result, errors.Error) {
-	return exec(id, param, transaction)
+	return exec(context.Background(), id, param, transaction)
+}
+
+func (transaction *Transaction) ExecContext(ctx context.Context, id string, param interface{}) (sql.Result, errors.Error) {
+	return exec(ctx, id, param, transaction)
 }
 
-func (transaction *Transaction) prepare(sql string) (*sql.Stmt, error) {
-	return transaction.tx.Prepare(sql)
+func (transaction *Transaction) prepare(ctx context.Context, sql string) (*sql.Stmt, error) {
+	return transaction.tx.PrepareContext(ctx, sql)
 }
 
 func (transaction *Transaction) statement(id string) *Statement {
@@ -0,0 +1,148 @@
+// Copyright 2014 li. All rights reserved.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+)
+
+// Driver counting the executed statements, every statement affects one row.
+type countDriver struct {
+	execs int32
+}
+
+func (d *countDriver) Open(name string) (driver.Conn, error) {
+	return &countConn{d}, nil
+}
+
+type countConn struct {
+	d *countDriver
+}
+
+func (c *countConn) Prepare(query string) (driver.Stmt, error) {
+	return &countStmt{c.d}, nil
+}
+
+func (c *countConn) Close() error {
+	return nil
+}
+
+func (c *countConn) Begin() (driver.Tx, error) {
+	return countTx{}, nil
+}
+
+type countStmt struct {
+	d *countDriver
+}
+
+func (s *countStmt) Close() error {
+	return nil
+}
+
+func (s *countStmt) NumInput() int {
+	return -1
+}
+
+func (s *countStmt) Exec(args []driver.Value) (driver.Result, error) {
+	atomic.AddInt32(&s.d.execs, 1)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *countStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+type countTx struct{}
+
+func (tx countTx) Commit() error {
+	return nil
+}
+
+func (tx countTx) Rollback() error {
+	return nil
+}
+
+var testDriver = &countDriver{}
+
+func init() {
+	sql.Register("light-count", testDriver)
+}
+
+func newTestDB(t *testing.T) *DB {
+	sqlDB, err := sql.Open("light-count", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := &Statement{id: "touch", op: UPDATE, dynamicer: func(param interface{}) (string, []interface{}) {
+		return "UPDATE t SET touched = 1", nil
+	}}
+	return &DB{db: sqlDB, statements: map[string]*Statement{"touch": statement}}
+}
+
+// Callback running the statement with the context, after the cancel if not nil.
+type touchCallBack struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	called bool
+	err    error
+}
+
+func (cb *touchCallBack) doTransaction(t Transaction) bool {
+	cb.called = true
+	if cb.cancel != nil {
+		cb.cancel()
+	}
+	_, cb.err = t.UpdateContext(cb.ctx, "touch", nil)
+	return true
+}
+
+func TestExecContext(t *testing.T) {
+	db := newTestDB(t)
+	defer db.db.Close()
+
+	execs := atomic.LoadInt32(&testDriver.execs)
+	if n, err := db.UpdateContext(context.Background(), "touch", nil); err != nil || n != 1 {
+		t.Fatalf("expected 1 row affected, got %d, error %v.", n, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.UpdateContext(ctx, "touch", nil); err == nil {
+		t.Error("expected the cancelled update failed.")
+	}
+	if _, err := db.ExecContext(ctx, "touch", nil); err == nil {
+		t.Error("expected the cancelled exec failed.")
+	}
+	if n := atomic.LoadInt32(&testDriver.execs) - execs; n != 1 {
+		t.Errorf("expected 1 statement executed, got %d.", n)
+	}
+}
+
+func TestDoTransactionContext(t *testing.T) {
+	db := newTestDB(t)
+	defer db.db.Close()
+
+	cb := &touchCallBack{ctx: context.Background()}
+	if err := db.DoTransactionContext(context.Background(), nil, cb); err != nil || cb.err != nil {
+		t.Fatalf("unexpected error %v, statement error %v.", err, cb.err)
+	}
+
+	// Not begun with the context done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cb = &touchCallBack{ctx: ctx}
+	if err := db.DoTransactionContext(ctx, nil, cb); err == nil || cb.called {
+		t.Errorf("expected the transaction not begun, got error %v.", err)
+	}
+
+	// Not committed with the context done in the transaction.
+	ctx, cancel = context.WithCancel(context.Background())
+	cb = &touchCallBack{ctx: ctx, cancel: cancel}
+	if err := db.DoTransactionContext(ctx, nil, cb); err == nil || cb.err == nil {
+		t.Errorf("expected the transaction not committed, got error %v, statement error %v.", err, cb.err)
+	}
+}
@@ -25,6 +25,22 @@ func Start() {
 
 }
 
+// Close all the DB pools.
+// For cross package, should only be called by framework on shutdown.
+func Close() {
+	dbMutex.Lock()
+	defer dbMutex.Unlock()
+
+	for name, db := range dataSources {
+		if db.db == nil {
+			continue
+		}
+		if err := db.db.Close(); err != nil {
+			log.Errorf("light/db: Close db %s error. %v", name, err)
+		}
+	}
+}
+
 func readDB() []*sqlmap.DB {
 	fNames := conf.List(DBLocation, func(fname string) bool {
 		return strings.HasPrefix(fname, "db-") &&
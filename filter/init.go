@@ -7,8 +7,9 @@ import (
 )
 
 func init() {
-	webcore.Register(&PanicFilter{})
-	webcore.Register(&RouteFilter{})
-	webcore.Register(&ParamsFilter{})
-	webcore.Register(&SessionFilter{})
+	webcore.RegisterDefault(&PanicFilter{})
+	webcore.RegisterDefault(&MethodOverrideFilter{})
+	webcore.RegisterDefault(&RouteFilter{})
+	webcore.RegisterDefault(&ParamsFilter{})
+	webcore.RegisterDefault(&SessionFilter{})
 }
@@ -0,0 +1,45 @@
+// Copyright 2014 li. All rights reserved.
+
+package filter
+
+import (
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"strings"
+)
+
+// Methods can be tunneled through POST.
+var overrideMethods = map[string]bool{"PUT": true, "PATCH": true, "DELETE": true}
+
+// MethodOverrideFilter lets html forms send PUT, PATCH and DELETE by POST,
+// with the "X-HTTP-Method-Override" header or the "_method" field of the urlencoded form.
+// It runs before the RouteFilter, so other bodies, such as multipart, are left for the handlers.
+type MethodOverrideFilter struct{}
+
+func (f *MethodOverrideFilter) DoFilter(c *web.Context, chain web.FilterChain) {
+	if c.Req.Method == "POST" {
+		method := c.Req.Header.Get("X-HTTP-Method-Override")
+		if method == "" && web.ResolveContentType(c.Req) == "application/x-www-form-urlencoded" {
+			if err := web.ParseForm(c.Req); err != nil {
+				paramsError(c, err)
+				return
+			}
+			method = c.Req.PostFormValue("_method")
+		}
+
+		if method = strings.ToUpper(strings.TrimSpace(method)); overrideMethods[method] {
+			c.Req.Method = method
+		}
+	}
+	chain.DoFilter(c)
+}
+
+// Reply the error of parsing params.
+func paramsError(c *web.Context, err error) {
+	if err == web.ErrBodyTooLarge {
+		webcore.Error(c, http.StatusRequestEntityTooLarge)
+	} else {
+		webcore.Error(c, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2014 li. All rights reserved.
+
+package filter
+
+import (
+	"bytes"
+	"github.com/roverli/light/webcore"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	app := webcore.NewApp("overrideApp", nil)
+	for _, method := range []string{"POST", "DELETE"} {
+		m := method
+		app.Handle(m+"/items", func() (int, string) {
+			return http.StatusOK, m
+		})
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("_method", "DELETE")
+	writer.Close()
+
+	cases := []struct {
+		contentType, body, header, expected string
+	}{
+		{"application/x-www-form-urlencoded", "_method=delete", "", "DELETE"},
+		{"text/plain", "", "DELETE", "DELETE"},
+		// Multipart bodies are not parsed before routing.
+		{writer.FormDataContentType(), buf.String(), "", "POST"},
+		{"text/plain", "_method=delete", "", "POST"},
+	}
+	for i, c := range cases {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		if c.header != "" {
+			req.Header.Set("X-HTTP-Method-Override", c.header)
+		}
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, req)
+		if body := resp.Body.String(); resp.Code != http.StatusOK || body != c.expected {
+			t.Errorf("case%d: expected 200 %s, got %d %s.", i, c.expected, resp.Code, body)
+		}
+	}
+}
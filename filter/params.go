@@ -29,6 +29,9 @@ func (f *ParamsFilter) DoFilter(c *web.Context, chain web.FilterChain) {
 		}
 	}()
 
-	web.ParseParams(c.Params, c.Req)
+	if err := web.ParseParams(c.Params, c.Req); err != nil {
+		paramsError(c, err)
+		return
+	}
 	chain.DoFilter(c)
 }
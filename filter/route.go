@@ -3,21 +3,142 @@
 package filter
 
 import (
+	"github.com/roverli/light/mux"
 	"github.com/roverli/light/web"
 	"github.com/roverli/light/webcore"
+	"net/http"
+	"sort"
+	"strings"
 )
 
+// RouteFilter routes the request and runs the route chain.
+// HEAD requests are served by the GET routes with the body discarded,
+// and OPTIONS requests are answered with the allowed methods if not routed.
 type RouteFilter struct{}
 
 func (f *RouteFilter) DoFilter(c *web.Context, chain web.FilterChain) {
-	c.RouteResult = webcore.Router.Route(c.Req.Method, c.Req.URL.Path)
+	c.RouteMethod = c.Req.Method
+	c.RouteResult = route(c, c.Req.Method)
 
-	switch c.RouteResult.IsMatch {
-	case true:
+	if c.Req.Method == "HEAD" && !c.RouteResult.IsMatch && c.RouteResult.Redirect == "" {
+		if result := route(c, "GET"); result.IsMatch || result.Redirect != "" {
+			c.RouteResult = result
+			c.RouteMethod = "GET"
+			c.Resp = &headResponse{c.Resp}
+		}
+	}
+
+	switch {
+	case c.RouteResult.IsMatch:
 		c.Params.Route = c.RouteResult.Parse()
 		chain.DoFilter(c)
 
-	case false:
-		//c.Status = web.Code404
+	case c.RouteResult.Redirect != "":
+		redirect(c)
+
+	case len(c.RouteResult.Allow) > 0:
+		c.Resp.Header().Set("Allow", strings.Join(allow(c.RouteResult.Allow), ", "))
+		if c.Req.Method == "OPTIONS" {
+			c.Resp.WriteHeader(http.StatusNoContent)
+		} else {
+			webcore.Error(c, http.StatusMethodNotAllowed)
+		}
+
+	default:
+		webcore.Error(c, http.StatusNotFound)
+	}
+}
+
+// Route the request by the method and the requested version.
+// The url with version prefix, eg. "/v2/users", is routed without the prefix first,
+// which is taken only if the route of the version matches.
+func route(c *web.Context, method string) *mux.Result {
+	if version, path := versionPrefix(c.Req.URL.Path); version != "" {
+		format := c.Format
+		if result := routePath(c, method, version, path); result.IsMatch && result.Version == version {
+			return result
+		}
+		c.Format = format
+	}
+	return routePath(c, method, requestVersion(c), c.Req.URL.Path)
+}
+
+// Route the path of the version, sets the format if routed without the suffix.
+// The suffix, eg. "/users.json", is stripped only if the full path doesn't match,
+// so the values of untyped params keep their dots, eg. "/files/notes.txt" for "/files/(name)",
+// while "/user/1.json" is routed to "/user/(id:int)" in json.
+func routePath(c *web.Context, method string, version string, path string) *mux.Result {
+	table := webcore.TableOf(c)
+	if table == nil {
+		return &mux.Result{}
+	}
+
+	result := table.RouteVersion(method, c.Req.Host, version, path)
+	if result.IsMatch || result.Redirect != "" {
+		return result
+	}
+
+	if stripped, format := web.ResolvePathFormat(path); format != "" {
+		if r := table.RouteVersion(method, c.Req.Host, version, stripped); r.IsMatch || r.Redirect != "" {
+			c.Format = format
+			return r
+		}
+	}
+	return result
+}
+
+// Add the implicit HEAD for GET, and OPTIONS to the allowed methods.
+func allow(methods []string) []string {
+	all := make([]string, 0, len(methods)+2)
+	all = append(all, methods...)
+	if containsString(methods, "GET") && !containsString(methods, "HEAD") {
+		all = append(all, "HEAD")
+	}
+	if !containsString(methods, "OPTIONS") {
+		all = append(all, "OPTIONS")
+	}
+	sort.Strings(all)
+	return all
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+// Discard the body for HEAD requests, keeping the headers and status.
+type headResponse struct {
+	http.ResponseWriter
+}
+
+func (r *headResponse) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (r *headResponse) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Redirect to the canonical url, keep the format suffix and the query.
+// Use 308 for the methods other than GET and HEAD to keep the method and body.
+func redirect(c *web.Context) {
+	url := c.RouteResult.Redirect
+	if c.Format != "" && !strings.HasSuffix(url, "/") {
+		url += "." + c.Format
+	}
+	if c.Req.URL.RawQuery != "" {
+		url += "?" + c.Req.URL.RawQuery
+	}
+
+	code := http.StatusMovedPermanently
+	if c.Req.Method != "GET" && c.Req.Method != "HEAD" {
+		code = http.StatusPermanentRedirect
 	}
+	http.Redirect(c.Resp, c.Req, url, code)
 }
@@ -0,0 +1,65 @@
+// Copyright 2014 li. All rights reserved.
+
+package filter
+
+import (
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type user struct {
+	Id   int    `$:"id"`
+	Name string `$:"name"`
+}
+
+func TestRouteFormat(t *testing.T) {
+	app := webcore.NewApp("formatApp", nil)
+	app.Handle("GET/users", func() (int, interface{}) {
+		return http.StatusOK, []user{{Id: 1, Name: "li"}}
+	})
+	app.Handle("GET/files/(name)", func(p struct {
+		Name string `$:"name"`
+	}) (int, string) {
+		return http.StatusOK, p.Name
+	})
+	app.Handle("GET/static/(*file)", func(p struct {
+		File string `$:"file"`
+	}) (int, string) {
+		return http.StatusOK, p.File
+	})
+	app.Handle("GET/user/(id:int)", func(u user) (int, interface{}) {
+		return http.StatusOK, u
+	})
+	app.Handle("GET/item/(id)", func(p struct {
+		Id string `$:"id"`
+	}) (int, interface{}) {
+		return http.StatusOK, p
+	})
+
+	cases := []struct {
+		url, body string
+	}{
+		{"/users.xml", `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<user><Id>1</Id><Name>li</Name></user>`},
+		{"/users.json", `[{"Id":1,"Name":"li"}]`},
+		// Param values keep their dots.
+		{"/files/notes.txt", "notes.txt"},
+		{"/files/notes.json", "notes.json"},
+		{"/static/css/app.json", "css/app.json"},
+		// Typed params take the suffix as the format.
+		{"/user/1.json", `{"Id":1,"Name":""}`},
+		{"/user/1.xml", `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<user><Id>1</Id><Name></Name></user>`},
+		// Untyped params keep it in the value.
+		{"/item/1.json", `{"Id":"1.json"}`},
+		{"/item/1.xml", `{"Id":"1.xml"}`},
+	}
+	for _, c := range cases {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", c.url, nil))
+		if body := strings.TrimSpace(resp.Body.String()); resp.Code != http.StatusOK || body != c.body {
+			t.Errorf("%s: expected 200 %s, got %d %s.", c.url, c.body, resp.Code, body)
+		}
+	}
+}
@@ -3,7 +3,6 @@
 package filter
 
 import (
-	"github.com/roverli/light/log"
 	"github.com/roverli/light/web"
 	"github.com/roverli/light/webcore"
 )
@@ -11,24 +10,11 @@ import (
 type SessionFilter struct {
 }
 
-// TODO compatibe not use session case.
+// SessionFilter sets a lazy session, which is created only when the handler writes it.
+// Session is nil if no session configured.
 func (f *SessionFilter) DoFilter(c *web.Context, chain web.FilterChain) {
-
-	session, err := webcore.SessionManager.Get(c.Req)
-	if err != nil {
-		log.Errorf("light/filter: Get session error. %v", err)
-		//c.Status = web.Code500
-		return
-	}
-
-	if session == nil {
-		session, err = webcore.SessionManager.Create(c.Req, c.Resp)
+	if manager := webcore.AppOf(c).SessionManager; manager != nil {
+		c.Session = manager.Lazy(c.Req, c.Resp)
 	}
-	if err != nil {
-		log.Errorf("light/filter: Create session error. %v", err)
-		return
-	}
-
-	c.Session = session
 	chain.DoFilter(c)
 }
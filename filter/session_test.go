@@ -0,0 +1,77 @@
+// Copyright 2014 li. All rights reserved.
+
+package filter
+
+import (
+	"fmt"
+	"github.com/roverli/light/conf"
+	"github.com/roverli/light/session"
+	_ "github.com/roverli/light/session/memory"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionFilter(t *testing.T) {
+	handlers := func(app *webcore.App) {
+		app.Handle("GET/read", func(s session.Session) (int, string) {
+			if s == nil {
+				return http.StatusOK, "none"
+			}
+			return http.StatusOK, fmt.Sprint("read ", s.GetAttribute("name"))
+		})
+		app.Handle("GET/write", func(s session.Session) (int, string) {
+			s.SetAttribute("name", "li")
+			return http.StatusOK, "write"
+		})
+		app.Handle("GET/plain", func() (int, string) {
+			return http.StatusOK, "plain"
+		})
+	}
+
+	app := webcore.NewApp("sessionApp", nil)
+	app.SessionConfig = conf.Config{"store": "memory", "enableCookie": "on"}
+	handlers(app)
+
+	cases := []struct {
+		url, body string
+		cookie    bool
+	}{
+		{"/plain", "plain", false},
+		{"/read", "read <nil>", false},
+		{"/write", "write", true},
+	}
+	for _, c := range cases {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", c.url, nil))
+		cookies := resp.Header()["Set-Cookie"]
+		if resp.Body.String() != c.body || (len(cookies) > 0) != c.cookie {
+			t.Errorf("%s: expected %s with cookie %v, got %s %v.", c.url, c.body, c.cookie, resp.Body.String(), cookies)
+		}
+	}
+
+	// The session is found by the cookies.
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/write", nil))
+	req := httptest.NewRequest("GET", "/read", nil)
+	for _, cookie := range resp.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	resp = httptest.NewRecorder()
+	app.ServeHTTP(resp, req)
+	if body := resp.Body.String(); body != "read li" {
+		t.Errorf("expected the session found by the cookies, got %s.", body)
+	}
+
+	// No session config, no session.
+	none := webcore.NewApp("noSessionApp", nil)
+	handlers(none)
+	for _, url := range []string{"/plain", "/read"} {
+		resp := httptest.NewRecorder()
+		none.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		if resp.Code != http.StatusOK || len(resp.Header()["Set-Cookie"]) > 0 || none.SessionManager != nil {
+			t.Errorf("%s: unexpected response %d %s without session.", url, resp.Code, resp.Body.String())
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 li. All rights reserved.
+
+package filter
+
+import (
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"mime"
+	"regexp"
+	"strings"
+)
+
+var (
+	vendorVersion = regexp.MustCompile(`^vnd\..+\.v([0-9][0-9.]*)$`) // eg. vnd.app.v2
+	prefixVersion = regexp.MustCompile(`^/v([0-9][0-9.]*)(/.*)?$`)   // eg. /v2/users
+)
+
+// Resolve the requested API version by the version header, the config "routeVersionHeader"
+// defaults to "X-Api-Version", then by the Accept media types, such as
+// "application/vnd.app.v2+json" or "application/json; version=2". Empty if not requested.
+func requestVersion(c *web.Context) string {
+	header := webcore.AppOf(c).Config.String("routeVersionHeader", "X-Api-Version")
+	if version := strings.TrimSpace(c.Req.Header.Get(header)); version != "" {
+		return strings.TrimPrefix(version, "v")
+	}
+
+	for _, accept := range strings.Split(c.Req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		if version := params["version"]; version != "" {
+			return strings.TrimPrefix(version, "v")
+		}
+
+		subtype := mediaType[strings.Index(mediaType, "/")+1:]
+		if i := strings.Index(subtype, "+"); i != -1 {
+			subtype = subtype[:i]
+		}
+		if m := vendorVersion.FindStringSubmatch(subtype); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// Split the version prefix of the url path, such as "/v2/users" to "2" and "/users".
+// Returns "" and the origin path if no version prefix.
+func versionPrefix(path string) (string, string) {
+	m := prefixVersion.FindStringSubmatch(path)
+	if m == nil {
+		return "", path
+	}
+	if m[2] == "" {
+		return m[1], "/"
+	}
+	return m[1], m[2]
+}
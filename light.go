@@ -3,14 +3,22 @@
 package light
 
 import (
+	"context"
 	"github.com/roverli/light/conf"
 	"github.com/roverli/light/db"
 	_ "github.com/roverli/light/filter"
 	"github.com/roverli/light/hook"
 	"github.com/roverli/light/log"
 	_ "github.com/roverli/light/session/memory"
+	"github.com/roverli/light/web"
 	"github.com/roverli/light/webcore"
+	"github.com/roverli/utils/errors"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 const Version = "0.1.0"
@@ -22,37 +30,190 @@ var (
 	HttpUrl     = conf.App.String("httpUrl", "/")
 	HttpSsl     = conf.App.Bool("httpSsl", false)
 	HttpSslCert = conf.App["httpSslCert"]
-	HttpSslKey  = conf.App["HttpSslKey"]
+	HttpSslKey  = conf.App.String("httpSslKey", conf.App["HttpSslKey"])
 	ServeStatic = conf.App.Bool("serveStatic", false)
 	StaticUrl   = conf.App["staticUrl"]
 	StaticDir   = conf.App["staticDir"]
+
+	HttpSslMinVersion   = conf.App.String("httpSslMinVersion", "1.2")
+	HttpSslCiphers      = conf.App["httpSslCiphers"]      // Comma separated suite names, empty for go defaults
+	HttpSslRedirectPort = conf.App["httpSslRedirectPort"] // Plain http port redirecting to https, empty for none
+
+	ShutdownTimeout = conf.App.Int("shutdownTimeout", 30) // In second
 )
 
+// NewApp creates an app serving as a http.Handler, which can mount into other servers.
+// The package functions, such as Handle, register to the default app served by StartHttp.
+func NewApp(name string, config conf.Config) *webcore.App {
+	return webcore.NewApp(name, config)
+}
+
 // Handle registers the handler for the given restful pattern.
-func Handle(url string, handler interface{}) {
-	webcore.Handle(url, handler)
+func Handle(url string, handler interface{}, opts ...webcore.Option) {
+	webcore.Handle(url, handler, opts...)
 }
 
-func StartHttp() {
-	defer func() {
-		hook.ShutDown()
-	}()
+// Unhandle unregisters the handler of the restful pattern, it can be called while serving.
+func Unhandle(url string, opts ...webcore.Option) {
+	webcore.Unhandle(url, opts...)
+}
+
+// Provide registers the provider for injecting the handler arguments by type,
+// in webcore.RequestScope by default. See webcore.Provide.
+func Provide(provider interface{}, scope ...webcore.Scope) {
+	webcore.Provide(provider, scope...)
+}
 
+// Controller registers the action methods of the controller under the url prefix,
+// such as Controller("/users", &UserController{}). See webcore.Controller.
+func Controller(prefix string, controller interface{}, opts ...webcore.Option) {
+	webcore.Controller(prefix, controller, opts...)
+}
+
+// URL builds the url of the route named by webcore.Name option.
+func URL(name string, params interface{}) (string, errors.Error) {
+	return webcore.URL(name, params)
+}
+
+// Group creates a route group sharing the url prefix and filters.
+func Group(prefix string, filters ...web.Filter) *webcore.Group {
+	return webcore.NewGroup(prefix, filters...)
+}
+
+// ErrorHandler registers the handler to reply requests failed with the given status code.
+func ErrorHandler(code int, handler func(c *web.Context, code int)) {
+	webcore.ErrorHandler(code, handler)
+}
+
+// ErrorStatus maps the error returned by handlers to the status code.
+func ErrorStatus(err error, code int) {
+	webcore.ErrorStatus(err, code)
+}
+
+// ErrorView registers the view template to render for the given status code.
+func ErrorView(code int, tpl string) {
+	webcore.ErrorView(code, tpl)
+}
+
+// StartHttp starts the application and serves until SIGINT or SIGTERM.
+// It serves https if "httpSsl" is on, the certificate is reloaded on SIGHUP.
+// On signal, it stops accepting connections, ends the event streams, drains the active requests
+// in "shutdownTimeout" seconds, then cancels the request contexts and closes the rest,
+// then runs the shutdown hooks, closes the session manager and the db pools in order.
+func StartHttp() {
 	log.Infof("Start application %s, light framework version %s.\n", AppName, Version)
 
 	db.Start()
-	webcore.Start()
+	if err := webcore.Start(); err != nil {
+		log.Errorf("Start application %s fail. %v\n", AppName, err)
+		shutDown()
+		return
+	}
 	hook.Start()
 
+	serveMux := http.NewServeMux()
 	if ServeStatic {
-		http.Handle(StaticUrl, http.StripPrefix(StaticUrl, http.FileServer(http.Dir(conf.ROOT+StaticUrl))))
+		serveMux.Handle(StaticUrl, http.StripPrefix(StaticUrl, http.FileServer(http.Dir(conf.ROOT+StaticUrl))))
 	}
-	http.HandleFunc(HttpUrl, func(w http.ResponseWriter, r *http.Request) {
-		webcore.Invoke(w, r)
-	})
+	serveMux.Handle(HttpUrl, webcore.DefaultApp)
 
-	err := http.ListenAndServe(HttpAddr+":"+HttpPort, nil)
-	if err != nil {
-		log.Errorf("Start application %s fail.\n", AppName, err)
+	server := &http.Server{Addr: HttpAddr + ":" + HttpPort, Handler: serveMux}
+	var redirect *http.Server
+
+	if HttpSsl {
+		tlsConfig, err := newTLSConfig()
+		if err != nil {
+			log.Errorf("Start application %s fail. %v\n", AppName, err)
+			shutDown()
+			return
+		}
+		server.TLSConfig = tlsConfig
+
+		if manager := webcore.DefaultApp.SessionManager; manager != nil {
+			manager.SetSecure(true)
+		}
+
+		if HttpSslRedirectPort != "" {
+			redirect = &http.Server{Addr: HttpAddr + ":" + HttpSslRedirectPort, Handler: redirectHandler()}
+			go func() {
+				if err := redirect.ListenAndServe(); err != http.ErrServerClosed {
+					log.Errorf("Start https redirect of application %s fail. %v\n", AppName, err)
+				}
+			}()
+		}
+	}
+
+	if redirect != nil {
+		server.RegisterOnShutdown(func() { redirect.Close() })
+	}
+	server.RegisterOnShutdown(webcore.DefaultApp.EndStreams)
+
+	// Notify before listening, so the signals are not missed.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	listen := server.ListenAndServe
+	if HttpSsl {
+		// Certificate is provided by the tls config.
+		listen = func() error { return server.ListenAndServeTLS("", "") }
+	}
+	if err := serve(server, listen, signals, time.Duration(ShutdownTimeout)*time.Second); err != nil {
+		log.Errorf("Start application %s fail. %v\n", AppName, err)
+		if redirect != nil {
+			redirect.Close()
+		}
 	}
+
+	shutDown()
+}
+
+// Serve by the listen function until the signal, then drain the active requests in the timeout.
+// The request contexts are kept while draining, they are cancelled after the timeout
+// and the connections still active are closed. It returns the error if listening fails.
+func serve(server *http.Server, listen func() error, signals <-chan os.Signal, timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.BaseContext = func(net.Listener) context.Context { return ctx }
+
+	drained := make(chan struct{})
+	failed := make(chan struct{})
+	go func() {
+		defer close(drained)
+
+		select {
+		case sig := <-signals:
+			log.Infof("Receive signal %v, shutdown application %s.\n", sig, AppName)
+		case <-failed:
+			return
+		}
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), timeout)
+		defer cancelShutdown()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Errorf("Drain requests of application %s fail, close the connections. %v\n", AppName, err)
+			cancel()
+			server.Close()
+		}
+	}()
+
+	err := listen()
+	if err == http.ErrServerClosed {
+		<-drained
+		return nil
+	}
+	close(failed)
+	return err
+}
+
+// Run the shutdown hooks, then close the session manager and the db pools.
+func shutDown() {
+	hook.ShutDown()
+
+	if manager := webcore.DefaultApp.SessionManager; manager != nil {
+		manager.Close()
+	}
+	db.Close()
+
+	log.Infof("Application %s shutdown.\n", AppName)
 }
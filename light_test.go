@@ -0,0 +1,145 @@
+// Copyright 2014 li. All rights reserved.
+
+package light
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServeDrain(t *testing.T) {
+	started := make(chan struct{}, 3)
+	streamsEnd := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("slow"))
+	})
+	// Waits on the request context, which is kept while draining.
+	mux.HandleFunc("/ctx", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-r.Context().Done():
+			w.Write([]byte("cancelled"))
+		case <-time.After(200 * time.Millisecond):
+			w.Write([]byte("ctx"))
+		}
+	})
+	// Ends by the separate shutdown signal.
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-streamsEnd
+		w.Write([]byte("stream"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: mux}
+	server.RegisterOnShutdown(func() { close(streamsEnd) })
+	signals := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(server, func() error { return server.Serve(ln) }, signals, 5*time.Second)
+	}()
+
+	paths := []string{"/slow", "/ctx", "/stream"}
+	bodies := make(chan string, len(paths))
+	for _, path := range paths {
+		go func(url string) {
+			resp, err := http.Get(url)
+			if err != nil {
+				bodies <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := ioutil.ReadAll(resp.Body)
+			bodies <- string(body)
+		}("http://" + ln.Addr().String() + path)
+	}
+	for range paths {
+		<-started
+	}
+
+	begin := time.Now()
+	signals <- syscall.SIGTERM
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("expected the stream ended on shutdown, drained in %v.", elapsed)
+	}
+
+	received := map[string]bool{}
+	for range paths {
+		received[<-bodies] = true
+	}
+	if !received["slow"] || !received["ctx"] || !received["stream"] {
+		t.Errorf("expected the requests drained, got %v.", received)
+	}
+}
+
+func TestServeDrainTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+		<-block
+	})}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(server, func() error { return server.Serve(ln) }, signals, 50*time.Millisecond)
+	}()
+
+	failed := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		failed <- err
+	}()
+	<-started
+
+	signals <- syscall.SIGTERM
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected serve returned after the timeout.")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Error("expected the request context cancelled after the timeout.")
+	}
+	if err := <-failed; err == nil {
+		t.Error("expected the connection closed after the timeout.")
+	}
+}
+
+func TestServeListenFail(t *testing.T) {
+	server := &http.Server{Addr: "bad address"}
+	if err := serve(server, server.ListenAndServe, make(chan os.Signal), time.Second); err == nil {
+		t.Error("expected the listen error.")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"github.com/roverli/utils/errors"
+	"net/url"
+	"strings"
+)
+
+// Build the url path of the pattern by filling the params,
+// the values must satisfy the regex of the pieces.
+// Returns the params not used by the pattern, for query string for example.
+func Build(pattern string, params map[string]string) (string, map[string]string, errors.Error) {
+	p, err := Compile(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.Build(params)
+}
+
+// Pattern is the parsed url pattern, for building the urls repeatedly.
+type Pattern struct {
+	path *path
+}
+
+// Compile parses the url pattern for building the urls.
+func Compile(pattern string) (*Pattern, errors.Error) {
+	p, err := initPath(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &Pattern{path: p}, nil
+}
+
+// Build the url path by filling the params, keeping the trailing slash of the pattern, see Build.
+func (pt *Pattern) Build(params map[string]string) (string, map[string]string, errors.Error) {
+	p, pattern := pt.path, pt.path.origin
+
+	used := make(map[string]bool)
+	strs := make([]string, 0, len(p.pieces))
+	for i, pc := range p.pieces {
+		// Omit the optional pieces from the first missing one.
+		if pc.optional && params[pc.name] == "" {
+			for _, rest := range p.pieces[i:] {
+				if params[rest.name] != "" {
+					return "", nil, errors.Newf("light/mux: param %s of url %s is missing.", pc.name, pattern)
+				}
+			}
+			break
+		}
+
+		str, err := fill(pc, pattern, params, used)
+		if err != nil {
+			return "", nil, err
+		}
+		strs = append(strs, str)
+	}
+
+	rest := make(map[string]string)
+	for k, v := range params {
+		if !used[k] {
+			rest[k] = v
+		}
+	}
+
+	url := pathSep + strings.Join(strs, pathSep)
+	if p.slash && len(strs) > 0 {
+		url += pathSep
+	}
+	return url, rest, nil
+}
+
+// Param of the pattern, such as "(id:int)".
+type Param struct {
+	Name string
+	Type string // Named pattern, such as "int", empty for none
+}
+
+// Params returns the named params of the pattern in order, the unnamed ones are omitted.
+func Params(pattern string) ([]Param, errors.Error) {
+	p, err := initPath(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []Param
+	for _, pc := range p.pieces {
+		parts := []*piece{pc}
+		if pc.prio == multiM {
+			parts = pc.parts
+		}
+		for _, part := range parts {
+			if part.prio == preciseM || part.name == "" {
+				continue
+			}
+			param := Param{Name: part.name}
+			if part.typ != nil {
+				param.Type = part.typ.name
+			}
+			params = append(params, param)
+		}
+	}
+	return params, nil
+}
+
+// Fill the piece by the params, and mark the used.
+func fill(pc *piece, pattern string, params map[string]string, used map[string]bool) (string, errors.Error) {
+	switch pc.prio {
+	case preciseM:
+		return pc.name, nil
+	case multiM:
+		str := ""
+		for _, part := range pc.parts {
+			s, err := fill(part, pattern, params, used)
+			if err != nil {
+				return "", err
+			}
+			str += s
+		}
+		return str, nil
+	}
+
+	if pc.name == "" {
+		return "", errors.Newf("light/mux: can't fill unnamed piece of url %s.", pattern)
+	}
+
+	val, ok := params[pc.name]
+	if !ok || val == "" {
+		return "", errors.Newf("light/mux: param %s of url %s is missing.", pc.name, pattern)
+	}
+	if pc.regex != nil && !pc.matchRegex(val) {
+		return "", errors.Newf("light/mux: param %s=%s doesn't match url %s.", pc.name, val, pattern)
+	}
+	used[pc.name] = true
+
+	if pc.prio == catchM {
+		return escapeRest(val), nil
+	}
+	return pc.prefix + url.PathEscape(val) + pc.suffix, nil
+}
+
+// Escape the pieces of the catch-all value.
+func escapeRest(val string) string {
+	strs := splitTrim(val, pathSep)
+	for i, str := range strs {
+		strs[i] = url.PathEscape(str)
+	}
+	return strings.Join(strs, pathSep)
+}
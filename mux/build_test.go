@@ -0,0 +1,56 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	url1, rest1, err1 := Build("/home/(id)/page(num:^[0-9]+$)", map[string]string{"id": "a b", "num": "2", "q": "x"})
+	assertTrue(err1 == nil && url1 == "/home/a%20b/page2", "case1", t)
+	assertTrue(reflect.DeepEqual(rest1, map[string]string{"q": "x"}), "case1", t)
+
+	_, _, err2 := Build("/home/page(num:^[0-9]+$)", map[string]string{"num": "x"})
+	assertTrue(err2 != nil, "case2", t)
+
+	_, _, err3 := Build("/home/(id)", map[string]string{})
+	assertTrue(err3 != nil, "case3", t)
+
+	_, _, err4 := Build("/home/(:^[0-9]+$)", map[string]string{})
+	assertTrue(err4 != nil, "case4", t)
+
+	url5, _, err5 := Build("/", nil)
+	assertTrue(err5 == nil && url5 == "/", "case5", t)
+
+	url6, _, err6 := Build("/static/(*file)", map[string]string{"file": "css/a b.css"})
+	assertTrue(err6 == nil && url6 == "/static/css/a%20b.css", "case6", t)
+
+	url7, _, err7 := Build("/archive/(year)-(month)/(page?=1)", map[string]string{"year": "2014", "month": "02"})
+	assertTrue(err7 == nil && url7 == "/archive/2014-02", "case7", t)
+
+	url8, _, err8 := Build("/archive/(year)-(month)/(page?=1)", map[string]string{"year": "2014", "month": "02", "page": "2"})
+	assertTrue(err8 == nil && url8 == "/archive/2014-02/2", "case8", t)
+
+	url9, _, err9 := Build("/users/(id)/", map[string]string{"id": "1"})
+	assertTrue(err9 == nil && url9 == "/users/1/", "case9", t)
+
+	pattern, err10 := Compile("/users/(id)/posts/")
+	assertTrue(err10 == nil, "case10", t)
+	for _, id := range []string{"1", "2"} {
+		url10, _, err10 := pattern.Build(map[string]string{"id": id})
+		assertTrue(err10 == nil && url10 == "/users/"+id+"/posts/", "case10", t)
+	}
+}
+
+func TestParams(t *testing.T) {
+	params, err := Params("/archive/(year:int)-(month)/(:^[a-z]+$)/(*rest)")
+	assertTrue(err == nil && reflect.DeepEqual(params, []Param{{"year", "int"}, {"month", ""}, {"rest", ""}}), "case1", t)
+
+	params, err = Params("/list/(page?=1)")
+	assertTrue(err == nil && reflect.DeepEqual(params, []Param{{"page", ""}}), "case2", t)
+
+	_, err = Params("/(*rest)/a")
+	assertTrue(err != nil, "case3", t)
+}
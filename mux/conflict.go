@@ -0,0 +1,133 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conflict of two paths under the same method, host and version.
+// The path is unreachable if the other one with the same pieces
+// is added before, or ambiguous if both have the same priority
+// and may match the same url, then the one added first wins.
+type Conflict struct {
+	Method      string
+	Host        string
+	Url         string
+	Other       string
+	Version     string
+	Unreachable bool
+	seq         int // Adding order of the path
+}
+
+func (c Conflict) String() string {
+	method := c.Method
+	if c.Host != "" {
+		method += " " + c.Host
+	}
+	url := c.Url
+	if c.Version != "" {
+		url += " (v" + c.Version + ")"
+	}
+	if c.Unreachable {
+		return fmt.Sprintf("%s %s is unreachable, shadowed by %s", method, url, c.Other)
+	}
+	return fmt.Sprintf("%s %s is ambiguous with %s", method, url, c.Other)
+}
+
+// Find the conflicts of the paths in the trie.
+func (t *hostTree) conflicts(method string) []Conflict {
+	var conflicts []Conflict
+	newConflict := func(p *path, other *path, unreachable bool) Conflict {
+		c := Conflict{Method: method, Url: p.origin, Other: other.origin, Version: p.version, Unreachable: unreachable, seq: p.seq}
+		if t.host != nil {
+			c.Host = t.host.origin
+		}
+		return c
+	}
+
+	// The paths omitting optional pieces are overridden by the defined ones, not conflicts.
+	var paths []*path
+	t.root.walk(func(n *node) {
+		defined := make([]*path, 0, len(n.paths))
+		for _, p := range n.paths {
+			if !p.variant {
+				defined = append(defined, p)
+			}
+		}
+
+		for i, p := range defined {
+			for _, other := range defined[:i] {
+				if other.slash == p.slash && other.version == p.version {
+					conflicts = append(conflicts, newConflict(p, other, true))
+					break
+				}
+			}
+		}
+
+		for _, p := range paths {
+			for _, other := range defined {
+				if p.version == other.version && p.compare(other) == _EQUAL && p.overlap(other) {
+					// Report the one added later.
+					if p.seq < other.seq {
+						conflicts = append(conflicts, newConflict(other, p, false))
+					} else {
+						conflicts = append(conflicts, newConflict(p, other, false))
+					}
+				}
+			}
+		}
+		paths = append(paths, defined...)
+	})
+	return conflicts
+}
+
+// Walk the node and its descendants.
+func (n *node) walk(f func(n *node)) {
+	f(n)
+	for _, c := range n.statics {
+		c.walk(f)
+	}
+	for _, c := range n.children {
+		c.walk(f)
+	}
+}
+
+// May the two paths of the same priority match the same url.
+// Regex pieces are supposed to overlap.
+func (p *path) overlap(other *path) bool {
+	for i, pc := range p.pieces {
+		o := other.pieces[i]
+		switch pc.prio {
+		case preciseM:
+			if pc.name != o.name {
+				return false
+			}
+		case pparamM, pregexM:
+			if !(strings.HasPrefix(pc.prefix, o.prefix) || strings.HasPrefix(o.prefix, pc.prefix)) ||
+				!(strings.HasSuffix(pc.suffix, o.suffix) || strings.HasSuffix(o.suffix, pc.suffix)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Sort the conflicts by method and adding order.
+type sortedConflicts []Conflict
+
+func (a sortedConflicts) Len() int {
+	return len(a)
+}
+
+func (a sortedConflicts) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a sortedConflicts) Less(i, j int) bool {
+	if a[i].Method != a[j].Method {
+		return a[i].Method < a[j].Method
+	}
+	return a[i].seq < a[j].seq
+}
@@ -3,23 +3,28 @@
 package mux
 
 // Url matching priority for piece.
-// Piece has five kinds of priority, from high to low:
-// preciseM, pregexM, pparamM, fregexM, fparamM.
+// Piece has seven kinds of priority, from high to low:
+// preciseM, pregexM, pparamM, multiM, fregexM, fparamM, catchM.
 type priority byte
 
 const (
-	fparamM  priority = iota // Fully param matching: /home/(id)
+	catchM   priority = iota // Catch-all matching the rest pieces: /static/(*file)
+	fparamM                  // Fully param matching: /home/(id)
 	fregexM                  // Fully regex matching: /home/(id:^123$)
+	multiM                   // Multiple params matching: /img/(name).(ext)
 	pparamM                  // Partial param matching: /home/page(id)
 	pregexM                  // Partial regex matching: /home/page(id:^123$)
 	preciseM                 // Precise matching: /home/profile
 )
 
 const (
-	pathSep  = "/" // Url path separator
-	lBrace   = "(" // Left brace
-	rBrace   = ")" // Right brace
-	regexSep = ":" // Seperator for regex key and value.
+	pathSep     = "/" // Url path separator
+	lBrace      = "(" // Left brace
+	rBrace      = ")" // Right brace
+	regexSep    = ":" // Seperator for regex key and value.
+	catchTag    = "*" // Tag for catch-all param name.
+	optionalTag = "?" // Tag for optional param name.
+	defaultSep  = "=" // Separator for optional param name and default value.
 )
 
 const (
@@ -0,0 +1,105 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trace of a path when explaining the routing.
+// "Reason" tells why the path is rejected, or why the matched one is not selected.
+type Trace struct {
+	Url      string
+	Host     string
+	Version  string
+	Matched  bool
+	Selected bool
+	Reason   string
+}
+
+func (t Trace) String() string {
+	url := t.Url
+	if t.Host != "" {
+		url = t.Host + " " + url
+	}
+	if t.Version != "" {
+		url += " (v" + t.Version + ")"
+	}
+	switch {
+	case t.Selected:
+		return url + ": selected"
+	case t.Matched:
+		return url + ": matched, " + t.Reason
+	}
+	return url + ": rejected, " + t.Reason
+}
+
+func (router *restRouter) Explain(method string, host string, url string) []Trace {
+	return router.load().Explain(method, host, url)
+}
+
+func (t *table) Explain(method string, host string, url string) []Trace {
+	if t == nil {
+		return nil
+	}
+
+	host = normalizeHost(host)
+	selected := t.lookup(t.trees[method], host, url, "")
+	if t.policy.DuplicateSlash == Strict && hasDuplicateSlash(url) {
+		selected = nil
+	}
+
+	paths := t.paths[method]
+	traces := make([]Trace, len(paths))
+	for i, p := range paths {
+		trace := Trace{Url: p.origin, Version: p.version, Reason: t.reject(p, host, url)}
+		if p.host != nil {
+			trace.Host = p.host.origin
+		}
+
+		if trace.Reason == "" {
+			trace.Matched = true
+			trace.Selected = p == selected
+			if !trace.Selected && selected != nil {
+				trace.Reason = "shadowed by " + selected.origin
+			}
+		}
+		traces[i] = trace
+	}
+	return traces
+}
+
+// Why the path doesn't match the host and url, empty if matches.
+func (t *table) reject(p *path, host string, url string) string {
+	if t.policy.DuplicateSlash == Strict && hasDuplicateSlash(url) {
+		return "duplicate slashes not allowed"
+	}
+	if p.host != nil && !p.host.match(splitTrim(host, hostSep)) {
+		return fmt.Sprintf("host %s doesn't match", host)
+	}
+
+	strs := splitTrim(url, pathSep)
+	if p.required > len(strs) || (!p.catch && p.depth < len(strs)) {
+		return fmt.Sprintf("%d pieces expected, got %d", p.depth, len(strs))
+	}
+
+	origins := splitTrim(p.origin, pathSep)
+	for i, pc := range p.pieces {
+		if i >= len(strs) {
+			break
+		}
+		matched := pc.match(strs[i])
+		if pc.prio == preciseM && t.policy.Case != Strict {
+			matched = strings.EqualFold(pc.name, strs[i])
+		}
+		if !matched {
+			return fmt.Sprintf("piece %s doesn't match %s", origins[i], strs[i])
+		}
+	}
+
+	if t.policy.TrailingSlash == Strict && p.slash != hasSlash(url) {
+		return "trailing slash mismatch"
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"github.com/roverli/utils/errors"
+	"strings"
+)
+
+const hostSep = "." // Host name separator
+
+// Paths of the same host pattern, the host is nil for any host.
+type hostTree struct {
+	host *path
+	root *node
+}
+
+// Init the host pattern, such as "(tenant).example.com".
+// The pieces have the same syntax as the url pieces, except catch-all.
+func initHost(host string) (*path, errors.Error) {
+	p, err := initPathBy(strings.ToLower(host), hostSep)
+	if err != nil {
+		return nil, err
+	}
+	if p.catch {
+		return nil, errors.Newf("init host error, catch-all not supported, host: %s.", host)
+	}
+	return p, nil
+}
+
+// Is the request host, in lower case without port, matching the host pattern.
+// The host is only split when there is a host pattern.
+func (t *hostTree) match(host string) bool {
+	return t.host == nil || t.host.match(splitTrim(host, hostSep))
+}
+
+// Sort the trees by host priority from high to low, any host at the last.
+type sortedHostTrees []*hostTree
+
+func (a sortedHostTrees) Len() int {
+	return len(a)
+}
+
+func (a sortedHostTrees) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a sortedHostTrees) Less(i, j int) bool {
+	if a[j].host == nil {
+		return a[i].host != nil
+	}
+	return a[i].host != nil && a[i].host.compare(a[j].host) > 0
+}
+
+// Lower case the host and strip the port, "[::1]:80" to "::1" for example.
+func normalizeHost(host string) string {
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.HasSuffix(host, "]") {
+		if j := strings.Index(host, "]"); j == -1 || j < i {
+			host = host[:i]
+		}
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
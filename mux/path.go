@@ -4,6 +4,7 @@ package mux
 
 import (
 	"github.com/roverli/utils/errors"
+	"strings"
 )
 
 // Path is representation for url .
@@ -13,11 +14,26 @@ type path struct {
 	pieces []*piece // pieces in order
 	parse  bool     // if need parse params
 	origin string   // the origin url
+	slash  bool     // if the origin url ends with slash
+	catch  bool     // if the last piece is catch-all
+	host   *path    // host pattern, nil for any host
+	seq    int      // adding order in the router
+	result *Result  // shared result if no params to parse
+
+	required int               // pieces before the optional ones, equals to depth if none
+	defaults map[string]string // default values of the omitted optional pieces
+	variant  bool              // if it's the path omitting optional pieces
+	version  string            // version of the path, empty for the default
 }
 
 func initPath(url string) (*path, errors.Error) {
+	return initPathBy(url, pathSep)
+}
+
+// Init the path by pieces separated by sep.
+func initPathBy(url string, sep string) (*path, errors.Error) {
 
-	strs := splitTrim(url, pathSep)
+	strs := splitTrim(url, sep)
 	pieces := make([]*piece, len(strs))
 
 	for i, v := range strs {
@@ -25,6 +41,12 @@ func initPath(url string) (*path, errors.Error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "init path error, path: %s.", url)
 		}
+		if piece.prio == catchM && i != len(strs)-1 {
+			return nil, errors.Newf("init path error, catch-all must be the last piece, path: %s.", url)
+		}
+		if i > 0 && pieces[i-1].optional && !piece.optional {
+			return nil, errors.Newf("init path error, optional pieces must be trailing, path: %s.", url)
+		}
 		pieces[i] = piece
 	}
 
@@ -35,30 +57,121 @@ func initPath(url string) (*path, errors.Error) {
 			break
 		}
 	}
-	return &path{len(pieces), pieces, isParse, url}, nil
+	p := &path{depth: len(pieces), pieces: pieces, parse: isParse, origin: url, required: len(pieces)}
+	for p.required > 0 && pieces[p.required-1].optional {
+		p.required--
+	}
+	p.slash = p.depth > 0 && strings.HasSuffix(strings.TrimSpace(url), sep)
+	p.catch = p.depth > 0 && pieces[p.depth-1].prio == catchM
+	if !isParse {
+		p.result = &Result{IsMatch: true, Url: url, path: p}
+	}
+	return p, nil
+}
+
+// Paths omitting the optional pieces from the last, with the default values.
+// Such as "/list/(page?=1)" has the variant "/list" with page=1.
+func (p *path) variants() []*path {
+	var variants []*path
+	defaults := make(map[string]string)
+	for depth := p.depth - 1; depth >= p.required; depth-- {
+		if pc := p.pieces[depth]; pc.def != "" {
+			defaults[pc.name] = pc.def
+		}
+
+		v := *p
+		v.depth = depth
+		v.pieces = p.pieces[:depth]
+		v.slash = false
+		v.variant = true
+		v.defaults = make(map[string]string, len(defaults))
+		for k, val := range defaults {
+			v.defaults[k] = val
+		}
+
+		v.parse = len(v.defaults) > 0
+		for _, pc := range v.pieces {
+			v.parse = v.parse || pc.isParseParam()
+		}
+		v.initResult()
+		variants = append(variants, &v)
+	}
+	return variants
+}
+
+// Init the result shared by the requests, if no params to parse including the host params.
+func (p *path) initResult() {
+	p.result = nil
+	if p.parse || (p.host != nil && p.host.parse) {
+		return
+	}
+	p.result = &Result{IsMatch: true, Url: p.origin, Version: p.version, path: p}
+	if p.host != nil {
+		p.result.Host = p.host.origin
+	}
 }
 
 func (p *path) parseParams(strs []string) (params map[string][]string) {
 	if p.parse {
 		params = make(map[string][]string)
-		for i, str := range strs {
-			if i < p.depth && p.pieces[i].isParseParam() {
-				k, v := p.pieces[i].parseParam(str)
-				arr, _ := params[k]
-				params[k] = append(arr, v)
+		for i, piece := range p.pieces {
+			if i >= len(strs) {
+				break
+			}
+			if piece.isParseParam() {
+				str := strs[i]
+				if piece.prio == catchM {
+					str = strings.Join(strs[i:], pathSep)
+				}
+				piece.parseParams(str, params)
 			}
 		}
+		for k, v := range p.defaults {
+			params[k] = append(params[k], v)
+		}
+	}
+	return
+}
+
+// Parse the params of the url in one scan like matching, without splitting the url.
+func (p *path) parseUrl(url string) (params map[string][]string) {
+	if !p.parse {
+		return
+	}
+
+	params = make(map[string][]string)
+	pos := 0
+	for _, piece := range p.pieces {
+		seg, next, ok := nextSegment(url, pos)
+		if !ok {
+			break
+		}
+		if piece.isParseParam() {
+			if piece.prio == catchM {
+				seg = strings.Join(splitTrim(url[pos:], pathSep), pathSep)
+			}
+			piece.parseParams(seg, params)
+		}
+		pos = next
+	}
+	for k, v := range p.defaults {
+		params[k] = append(params[k], v)
 	}
 	return
 }
 
 func (p *path) match(strs []string) bool {
 
-	if p.depth > len(strs) {
+	// Catch-all matches one or more pieces, optional pieces can be omitted,
+	// otherwise the depth must equal.
+	if p.required > len(strs) || (!p.catch && p.depth < len(strs)) {
 		return false
 	}
 
-	for i := p.depth - 1; i >= 0; i-- {
+	for i := len(strs) - 1; i >= 0; i-- {
+		if i >= p.depth {
+			continue
+		}
 		if !p.pieces[i].match(strs[i]) {
 			return false
 		}
@@ -3,6 +3,7 @@
 package mux
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -14,6 +15,12 @@ func TestPathInit(t *testing.T) {
 	//exceptional case
 	_, err2 := initPath(`/home/profile/a()bc`)
 	assertTrue(err2 != nil, "case p2", t)
+
+	_, err3 := initPath(`/static/(*file)/view`)
+	assertTrue(err3 != nil, "case p3", t)
+
+	_, err4 := initPath(`/static/f(*file)`)
+	assertTrue(err4 != nil, "case p4", t)
 }
 
 func TestPathCompare(t *testing.T) {
@@ -42,12 +49,12 @@ func TestPathCompare(t *testing.T) {
 func TestPathMath(t *testing.T) {
 	p0, _ := initPath(`/`)
 	assertTrue(p0.match([]string{}), "case p0", t)
-	assertTrue(p0.match([]string{"abc", "efg"}), "case p0", t)
-	assertTrue(p0.match([]string{"abc"}), "case p0", t)
+	assertFalse(p0.match([]string{"abc", "efg"}), "case p0", t)
+	assertFalse(p0.match([]string{"abc"}), "case p0", t)
 
 	p1, _ := initPath(`/home/`)
 	assertTrue(p1.match([]string{"home"}), "case p1", t)
-	assertTrue(p1.match([]string{"home", "asfasdf"}), "case p1", t)
+	assertFalse(p1.match([]string{"home", "asfasdf"}), "case p1", t)
 	assertFalse(p1.match([]string{"homex"}), "case p1", t)
 
 	p2, _ := initPath(`/home/profile`)
@@ -57,7 +64,7 @@ func TestPathMath(t *testing.T) {
 
 	p3, _ := initPath(`/home/pro(name:^[0-9]*$)le`)
 	assertTrue(p3.match([]string{"home", "pro123le"}), "case p3", t)
-	assertTrue(p3.match([]string{"home", "pro123le", "photo"}), "case p3", t)
+	assertFalse(p3.match([]string{"home", "pro123le", "photo"}), "case p3", t)
 	assertFalse(p3.match([]string{"home", "proxxle"}), "case p3", t)
 
 	p4, _ := initPath(`/home/pro(fi)le`)
@@ -73,8 +80,13 @@ func TestPathMath(t *testing.T) {
 	p6, _ := initPath(`/home/(profile)`)
 	assertTrue(p6.match([]string{"home", "123efg"}), "case p6", t)
 	assertTrue(p6.match([]string{"home", "bacxx"}), "case p6", t)
-	assertTrue(p6.match([]string{"home", "123", "abc"}), "case p6", t)
+	assertFalse(p6.match([]string{"home", "123", "abc"}), "case p6", t)
 	assertTrue(p6.match([]string{"home", "123"}), "case p6", t)
+
+	p7, _ := initPath(`/static/(*file)`)
+	assertTrue(p7.match([]string{"static", "a"}), "case p7", t)
+	assertTrue(p7.match([]string{"static", "a", "b", "c"}), "case p7", t)
+	assertFalse(p7.match([]string{"static"}), "case p7", t)
 }
 
 func TestPathParseParams(t *testing.T) {
@@ -105,4 +117,15 @@ func TestPathParseParams(t *testing.T) {
 	map5 := p5.parseParams([]string{"tony", "page123"})
 	v51 := map5["id"]
 	assertTrue(len(v51) == 2 && v51[0] == "tony" && v51[1] == "123", "case p4", t)
+
+	p6, _ := initPath(`/static/(*file)`)
+	map6 := p6.parseParams([]string{"static", "css", "main.css"})
+	v61 := map6["file"]
+	assertTrue(len(v61) == 1 && v61[0] == "css/main.css", "case p6", t)
+
+	// Parsed in one scan of the url as the split pieces.
+	map7 := p6.parseUrl("/static// css/main.css/")
+	assertTrue(reflect.DeepEqual(map7, map6), "case p7", t)
+	map8 := p4.parseUrl("tony//page123")
+	assertTrue(reflect.DeepEqual(map8, map4), "case p8", t)
 }
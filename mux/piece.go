@@ -19,10 +19,20 @@ type piece struct {
 
 	prio  priority       // The matching priority, also identify the matching type.
 	regex *regexp.Regexp // Instance for regular piece matching
+	typ   *paramType     // Named pattern, such as "int", nil for none
+
+	parts []*piece // Literal and param parts of multiple params matching, eg. (name).(ext)
+
+	optional bool   // Optional trailing piece, eg. (page?=1)
+	def      string // Default value of the optional piece
 }
 
 func initPiece(str string) (*piece, errors.Error) {
 
+	if groups := braceGroups(str); len(groups) > 1 {
+		return initMultiPiece(str, groups)
+	}
+
 	l := strings.Index(str, lBrace)
 	r := strings.LastIndex(str, rBrace)
 
@@ -39,6 +49,15 @@ func initPiece(str string) (*piece, errors.Error) {
 		return nil, errors.Newf(`bad url piece: %s, no content between "(" and ")"`, str)
 	}
 
+	if strings.HasPrefix(content, catchTag) {
+		if !fmatched || regexSepIndex != -1 {
+			return nil, errors.Newf(`bad url piece: %s, catch-all must be the whole piece without regex`, str)
+		}
+		p.name = strings.TrimSpace(content[len(catchTag):])
+		p.prio = catchM
+		return p, nil
+	}
+
 	if !fmatched {
 		p.prefix = string(strings.TrimSpace(str[0:l]))
 		p.suffix = string(strings.TrimSpace(str[r+1:]))
@@ -56,13 +75,17 @@ func initPiece(str string) (*piece, errors.Error) {
 		name := strings.TrimSpace(content[:regexSepIndex])
 		expr := strings.TrimSpace(content[regexSepIndex+1:])
 
-		regex, err := regexp.Compile(expr)
-		if err != nil {
-			return nil, errors.Newf(`bad url piece: %s, regex expression compile error`, str)
-		}
-
 		p.name = name
-		p.regex = regex
+		if typ := lookupType(expr); typ != nil {
+			p.typ = typ
+			p.regex = typ.regex
+		} else {
+			regex, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, errors.Newf(`bad url piece: %s, regex expression compile error`, str)
+			}
+			p.regex = regex
+		}
 
 		if fmatched {
 			p.prio = fregexM
@@ -71,9 +94,77 @@ func initPiece(str string) (*piece, errors.Error) {
 		}
 	}
 
+	// Optional param with default value, eg. (page?=1) or (page?=1:int)
+	if i := strings.Index(p.name, optionalTag); i != -1 {
+		def := strings.TrimSpace(p.name[i+1:])
+		if !fmatched || (def != "" && !strings.HasPrefix(def, defaultSep)) {
+			return nil, errors.Newf(`bad url piece: %s, optional param must be the whole piece as (name?=default)`, str)
+		}
+		p.name = strings.TrimSpace(p.name[:i])
+		p.optional = true
+		p.def = strings.TrimSpace(strings.TrimPrefix(def, defaultSep))
+	}
+
 	return p, nil
 }
 
+// Init the piece of multiple params, such as "(year)-(month)-(day)" or "img(name:int).(ext)".
+// The params are resolved from left to right, each ends before the next literal.
+func initMultiPiece(str string, groups [][2]int) (*piece, errors.Error) {
+	p := &piece{name: str, prio: multiM}
+
+	pos := 0
+	for _, g := range groups {
+		if literal := str[pos:g[0]]; literal != "" {
+			p.parts = append(p.parts, &piece{name: literal, prio: preciseM})
+		} else if pos > 0 {
+			return nil, errors.Newf(`bad url piece: %s, params must be separated by literals`, str)
+		}
+
+		part, err := initPiece(str[g[0] : g[1]+1])
+		if err != nil {
+			return nil, err
+		}
+		if part.prio != fparamM && part.prio != fregexM || part.optional {
+			return nil, errors.Newf(`bad url piece: %s, only params and regex supported in multiple params`, str)
+		}
+		p.parts = append(p.parts, part)
+		pos = g[1] + 1
+	}
+
+	if pos < len(str) {
+		p.parts = append(p.parts, &piece{name: str[pos:], prio: preciseM})
+	}
+	return p, nil
+}
+
+// Find the top level brace groups, nil if the braces are not balanced.
+func braceGroups(str string) [][2]int {
+	var groups [][2]int
+	depth, l := 0, 0
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case lBrace[0]:
+			if depth == 0 {
+				l = i
+			}
+			depth++
+		case rBrace[0]:
+			depth--
+			if depth < 0 {
+				return nil
+			}
+			if depth == 0 {
+				groups = append(groups, [2]int{l, i})
+			}
+		}
+	}
+	if depth != 0 {
+		return nil
+	}
+	return groups
+}
+
 // Is the piece matching the str.
 // Return true, when the str matching this piece.
 func (p *piece) match(str string) bool {
@@ -81,10 +172,13 @@ func (p *piece) match(str string) bool {
 	switch p.prio {
 	case preciseM:
 		return str == p.name
-	case fparamM:
+	case fparamM, catchM:
 		return true
 	case fregexM:
-		return p.regex.MatchString(str)
+		return p.matchRegex(str)
+	case multiM:
+		_, ok := p.matchParts(str, 0, false, nil)
+		return ok
 
 	case pparamM, pregexM:
 		strLen := len(str)
@@ -102,12 +196,87 @@ func (p *piece) match(str string) bool {
 			return partMatch
 		} else {
 			return partMatch &&
-				p.regex.Match([]byte(str[preLen:strLen-sufLen]))
+				p.matchRegex(str[preLen:strLen-sufLen])
 		}
 	}
 	panic("Never happen!")
 }
 
+// Is the str matching the regex, and the check of the named pattern.
+func (p *piece) matchRegex(str string) bool {
+	return p.regex.MatchString(str) && (p.typ == nil || p.typ.check == nil || p.typ.check(str))
+}
+
+// Match the parts from the index left to right, each param ends before
+// the first occurrence of the next literal that lets the rest match.
+// The param values are appended to vals if collect is true.
+func (p *piece) matchParts(str string, i int, collect bool, vals []string) ([]string, bool) {
+	if i == len(p.parts) {
+		return vals, str == ""
+	}
+
+	part := p.parts[i]
+	if part.prio == preciseM {
+		if !strings.HasPrefix(str, part.name) {
+			return nil, false
+		}
+		return p.matchParts(str[len(part.name):], i+1, collect, vals)
+	}
+
+	// The last param takes the rest.
+	if i == len(p.parts)-1 {
+		if str == "" || !part.match(str) {
+			return nil, false
+		}
+		if collect {
+			vals = append(vals, str)
+		}
+		return vals, true
+	}
+
+	literal := p.parts[i+1].name
+	for off := 1; off < len(str); off++ {
+		j := strings.Index(str[off:], literal)
+		if j == -1 {
+			break
+		}
+		off += j
+
+		val := str[:off]
+		if !part.match(val) {
+			continue
+		}
+		next := vals
+		if collect {
+			next = append(vals[:len(vals):len(vals)], val)
+		}
+		if rs, ok := p.matchParts(str[off:], i+1, collect, next); ok {
+			return rs, true
+		}
+	}
+	return nil, false
+}
+
+// Parse all the params of the piece into params.
+func (p *piece) parseParams(str string, params map[string][]string) {
+	if p.prio != multiM {
+		k, v := p.parseParam(str)
+		params[k] = append(params[k], v)
+		return
+	}
+
+	vals, _ := p.matchParts(str, 0, true, nil)
+	i := 0
+	for _, part := range p.parts {
+		if part.prio != preciseM && i < len(vals) {
+			if part.name != "" {
+				params[part.name] = append(params[part.name], vals[i])
+			}
+			i++
+		}
+	}
+}
+
 // Is need to parse the piece param.
 func (p *piece) isParseParam() bool {
 	return p.prio != preciseM && p.name != ""
@@ -123,6 +292,28 @@ func (p *piece) parseParam(str string) (string, string) {
 	return p.name, str
 }
 
+// Is the piece matching the same strings as other piece, regardless of the name.
+func (p *piece) sameKind(other *piece) bool {
+	if p.prio != other.prio || p.prefix != other.prefix || p.suffix != other.suffix || p.typ != other.typ {
+		return false
+	}
+	if p.prio == multiM {
+		if len(p.parts) != len(other.parts) {
+			return false
+		}
+		for i, part := range p.parts {
+			if !part.sameKind(other.parts[i]) || (part.prio == preciseM && part.name != other.parts[i].name) {
+				return false
+			}
+		}
+		return true
+	}
+	if p.regex == nil || other.regex == nil {
+		return p.regex == other.regex
+	}
+	return p.regex.String() == other.regex.String()
+}
+
 // Compare the matching priority.
 // Return postive value, when current piece has higher priority than other piece.
 // Return zero value, when current piece has same priority to other piece.
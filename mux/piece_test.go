@@ -140,3 +140,64 @@ func TestPieceParseParam(t *testing.T) {
 	p8, _ := initPiece(` page (:^ab.*c$) num `)
 	assertFalse(p8.isParseParam(), "case p8", t)
 }
+
+func TestPieceType(t *testing.T) {
+	p1, _ := initPiece("(id:int)")
+	assertTrue(p1.prio == fregexM && p1.match("-12"), "case p1", t)
+	assertFalse(p1.match("12a"), "case p1", t)
+	assertFalse(p1.match("99999999999999999999"), "case p1", t)
+
+	p2, _ := initPiece("(d:date)")
+	assertTrue(p2.match("2014-02-28"), "case p2", t)
+	assertFalse(p2.match("2014-02-30"), "case p2", t)
+
+	p3, _ := initPiece("post-(s:slug)")
+	assertTrue(p3.prio == pregexM && p3.match("post-hello-world"), "case p3", t)
+	assertFalse(p3.match("post-Hello"), "case p3", t)
+
+	p4, _ := initPiece("(u:uuid)")
+	assertTrue(p4.match("123e4567-e89b-12d3-a456-426614174000"), "case p4", t)
+	assertFalse(p4.match("123e4567"), "case p4", t)
+
+	Alias("hex", "^[0-9a-f]+$")
+	defer func() {
+		typesMu.Lock()
+		delete(paramTypes, "hex")
+		typesMu.Unlock()
+	}()
+	p5, _ := initPiece("(color:hex)")
+	assertTrue(p5.match("ff00ff") && !p5.match("xyz"), "case p5", t)
+
+	defer func() {
+		assertTrue(recover() != nil, "case p6", t)
+	}()
+	Alias("int", "^[0-9]+$")
+}
+
+func TestPieceMulti(t *testing.T) {
+	p1, _ := initPiece("(year:int)-(month)-(day)")
+	assertTrue(p1.prio == multiM && len(p1.parts) == 5, "case p1", t)
+	assertTrue(p1.match("2014-02-28") && !p1.match("x-02-28") && !p1.match("2014-02"), "case p1", t)
+	params1 := make(map[string][]string)
+	p1.parseParams("2014-02-28", params1)
+	assertTrue(reflect.DeepEqual(params1, map[string][]string{"year": {"2014"}, "month": {"02"}, "day": {"28"}}), "case p1", t)
+
+	// Backtrack to the dot before the extension.
+	p2, _ := initPiece("img(name).(ext:^[a-z]+$)")
+	params2 := make(map[string][]string)
+	p2.parseParams("imga.b.png", params2)
+	assertTrue(reflect.DeepEqual(params2, map[string][]string{"name": {"a.b"}, "ext": {"png"}}), "case p2", t)
+	assertFalse(p2.match("a.png"), "case p2", t)
+
+	p3, _ := initPiece("(id:^(a|b)$)")
+	assertTrue(p3.prio == fregexM, "case p3", t)
+
+	_, err4 := initPiece("(a)(b)")
+	assertTrue(err4 != nil, "case p4", t)
+
+	p5, _ := initPiece("(page?=1:int)")
+	assertTrue(p5.optional && p5.def == "1" && p5.name == "page" && p5.typ != nil, "case p5", t)
+
+	_, err6 := initPiece("page(num?=1)")
+	assertTrue(err6 != nil, "case p6", t)
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"github.com/roverli/utils/errors"
+	"strings"
+)
+
+// Mode of handling the urls not in the canonical form.
+type Mode byte
+
+const (
+	Lenient  Mode = iota // Match as the canonical url
+	Redirect             // Redirect to the canonical url
+	Strict               // Not match
+)
+
+var modes = map[string]Mode{
+	"lenient":  Lenient,
+	"redirect": Redirect,
+	"strict":   Strict,
+}
+
+// ParseMode parses the mode name, one of "lenient", "redirect" and "strict".
+func ParseMode(name string) (Mode, errors.Error) {
+	mode, ok := modes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Lenient, errors.Newf("light/mux: unknown policy mode %s.", name)
+	}
+	return mode, nil
+}
+
+// Policy of url normalization.
+// The canonical url has the trailing slash as the path defined,
+// no duplicate slashes, and the same case of the precise pieces.
+type Policy struct {
+	TrailingSlash  Mode
+	DuplicateSlash Mode
+	Case           Mode
+}
+
+// DefaultPolicy ignores the trailing and duplicate slashes, and matches case sensitively.
+var DefaultPolicy = Policy{TrailingSlash: Lenient, DuplicateSlash: Lenient, Case: Strict}
+
+// Is any mode redirect.
+func (policy Policy) redirect() bool {
+	return policy.TrailingSlash == Redirect || policy.DuplicateSlash == Redirect || policy.Case == Redirect
+}
+
+// Is the url ends with slash after some pieces.
+func hasSlash(url string) bool {
+	url = strings.TrimSpace(url)
+	return strings.HasSuffix(url, pathSep) && strings.Trim(url, pathSep+" ") != ""
+}
+
+func hasDuplicateSlash(url string) bool {
+	return strings.Contains(url, pathSep+pathSep)
+}
+
+// Is the url to redirect to the canonical one by the policy.
+func (policy Policy) needRedirect(p *path, url string) bool {
+	if policy.DuplicateSlash == Redirect && hasDuplicateSlash(url) {
+		return true
+	}
+	if policy.TrailingSlash == Redirect && p.slash != hasSlash(url) {
+		return true
+	}
+
+	if policy.Case == Redirect {
+		seg, pos, ok := nextSegment(url, 0)
+		for i := 0; ok && i < p.depth; i++ {
+			if pc := p.pieces[i]; pc.prio == preciseM && pc.name != seg {
+				return true
+			}
+			seg, pos, ok = nextSegment(url, pos)
+		}
+	}
+	return false
+}
+
+// Build the canonical url of the matched path.
+func (policy Policy) canonical(p *path, url string) string {
+	strs := splitTrim(url, pathSep)
+	for i := 0; i < p.depth && i < len(strs); i++ {
+		if pc := p.pieces[i]; pc.prio == preciseM {
+			strs[i] = pc.name
+		}
+	}
+
+	canonical := pathSep + strings.Join(strs, pathSep)
+	slash := p.slash
+	if policy.TrailingSlash == Lenient {
+		slash = hasSlash(url)
+	}
+	if slash && len(strs) > 0 {
+		canonical += pathSep
+	}
+	return canonical
+}
@@ -7,6 +7,9 @@ package mux
 import (
 	"github.com/roverli/utils/errors"
 	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 var _ Router = &restRouter{}
@@ -21,11 +24,60 @@ type Router interface {
 	// Add route url by specified methods.
 	Add(methods []string, url string)
 
-	// Start the router.
+	// Add route url by specified methods under the host pattern,
+	// such as "api.example.com" or "(tenant).example.com".
+	AddHost(methods []string, host string, url string)
+
+	// Add route url of the version by specified methods under the host pattern.
+	// The same url may be added with different versions, the empty version is the default.
+	AddVersion(methods []string, host string, version string, url string)
+
+	// Remove the route url of the methods under the host pattern and version,
+	// all methods if empty.
+	Remove(methods []string, host string, version string, url string)
+
+	// Set the url normalization policy, before start.
+	SetPolicy(policy Policy)
+
+	// Start the router, building the routing table from the added urls.
+	// Call again to apply the urls added or removed after start,
+	// the table is swapped atomically while routing.
 	Start() errors.Error
 
+	// Build the routing table from the added urls without starting it, so the caller
+	// can check the table and swap it atomically along with its own states.
+	Build() (Table, errors.Error)
+
+	// Get the conflicts found on start, ordered by method and adding order.
+	Conflicts() []Conflict
+
+	// Explain how the url is routed for the default version, by tracing each path of the method.
+	Explain(method string, host string, url string) []Trace
+
 	// Route for the corresponding method and url,and resolve the params.
 	Route(method string, url string) *Result
+
+	// Route for the corresponding method, host and url.
+	// The routes with host patterns take precedence over the ones without.
+	RouteHost(method string, host string, url string) *Result
+
+	// Route for the corresponding method, host and url, selecting the path of the version
+	// among the ones of the same url. If the version is empty or not found,
+	// the default version is selected, then the latest one.
+	RouteVersion(method string, host string, version string, url string) *Result
+}
+
+// Table is the immutable routing table built from the added urls.
+type Table interface {
+
+	// Get the conflicts of the table, ordered by method and adding order.
+	Conflicts() []Conflict
+
+	// Explain how the url is routed for the default version, by tracing each path of the method.
+	Explain(method string, host string, url string) []Trace
+
+	// Route for the corresponding method, host, version and url, see Router.RouteVersion.
+	RouteVersion(method string, host string, version string, url string) *Result
 }
 
 // The routing result.
@@ -33,37 +85,82 @@ type Router interface {
 // Otherwise,"IsMatch" will be true, and the "Url" string is the matched predefined path.
 // Even the "IsMatch" equals true, "Params" can be nil(the path doesn't need to be resloved).
 // So before use the params result, check whether params is nil first.
+// When "IsMatch" is false but the url matches under other methods,
+// "Allow" lists those methods in order.
+// When the url matches but is not canonical under redirect policy,
+// "IsMatch" is false and "Redirect" is the canonical url.
+// "Host" is the matched host pattern, empty for any host.
+// "Version" is the version of the matched path, empty for the default.
+// Results of the paths without params are shared, don't modify them.
 type Result struct {
-	IsMatch bool
-	Url     string
-	pieces  []string
-	path    *path
+	IsMatch  bool
+	Url      string
+	Allow    []string
+	Redirect string
+	Host     string
+	Version  string
+	reqUrl   string
+	reqHost  string
+	path     *path
 }
 
-// Parse pramas.
+// Parse pramas, including the host params.
 func (r Result) Parse() url.Values {
-	if r.IsMatch {
-		return r.path.parseParams(r.pieces)
+	if !r.IsMatch {
+		return nil
 	}
-	return nil
+
+	var params url.Values
+	if r.path.parse {
+		params = r.path.parseUrl(r.reqUrl)
+	}
+	if host := r.path.host; host != nil && host.parse {
+		if params == nil {
+			params = make(url.Values)
+		}
+		for k, v := range host.parseParams(splitTrim(r.reqHost, hostSep)) {
+			params[k] = append(params[k], v...)
+		}
+	}
+	return params
 }
 
+// Result for the urls matching nothing.
+var noMatch = &Result{}
+
 // Create a router by name.
 func New(name string) Router {
-	return &restRouter{name: name}
+	return &restRouter{name: name, policy: DefaultPolicy}
 }
 
 // Defined for origin url path.
 type routeUrl struct {
 	methods []string
+	host    string
+	version string
 	url     string
 }
 
-// Restful style struct for for Router interface
+// Restful style struct for for Router interface.
+// The added urls are built into an immutable table on start,
+// which is swapped atomically, so routing never takes a lock.
 type restRouter struct {
-	name       string
-	routeUrls  []routeUrl
-	urlMapping map[string]map[int][]*path
+	name string
+
+	mu        sync.Mutex // Guards routeUrls and policy
+	routeUrls []routeUrl
+	policy    Policy
+
+	table atomic.Value // *table
+}
+
+// Routing table built from the added urls.
+// Paths are kept in a trie for each method and host pattern.
+type table struct {
+	policy    Policy
+	trees     map[string][]*hostTree
+	paths     map[string][]*path // Paths of each method in adding order
+	conflicts []Conflict
 }
 
 func (router *restRouter) Name() string {
@@ -71,16 +168,105 @@ func (router *restRouter) Name() string {
 }
 
 func (router *restRouter) Add(methods []string, url string) {
-	router.routeUrls = append(router.routeUrls, routeUrl{methods, url})
+	router.AddHost(methods, "", url)
+}
+
+func (router *restRouter) AddHost(methods []string, host string, url string) {
+	router.AddVersion(methods, host, "", url)
+}
+
+func (router *restRouter) AddVersion(methods []string, host string, version string, url string) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.routeUrls = append(router.routeUrls, routeUrl{methods, host, version, url})
+}
+
+func (router *restRouter) Remove(methods []string, host string, version string, url string) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	routeUrls := make([]routeUrl, 0, len(router.routeUrls))
+	for _, r := range router.routeUrls {
+		if r.host == host && r.version == version && r.url == url {
+			if len(methods) == 0 {
+				continue
+			}
+
+			var rest []string
+			for _, m := range r.methods {
+				if !containsString(methods, m) {
+					rest = append(rest, m)
+				}
+			}
+			if len(rest) == 0 {
+				continue
+			}
+			r.methods = rest
+		}
+		routeUrls = append(routeUrls, r)
+	}
+	router.routeUrls = routeUrls
+}
+
+func (router *restRouter) SetPolicy(policy Policy) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	router.policy = policy
 }
 
 func (router *restRouter) Start() errors.Error {
-	urlMapping := make(map[string]map[int][]*path)
-	for _, routeUrl := range router.routeUrls {
+	t, err := router.Build()
+	if err != nil {
+		return err
+	}
+	router.table.Store(t)
+	return nil
+}
+
+func (router *restRouter) Build() (Table, errors.Error) {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+
+	t, err := newTable(router.routeUrls, router.policy)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Load the current table, nil before start.
+func (router *restRouter) load() *table {
+	t, _ := router.table.Load().(*table)
+	return t
+}
+
+func newTable(routeUrls []routeUrl, policy Policy) (*table, errors.Error) {
+	t := &table{
+		policy: policy,
+		trees:  make(map[string][]*hostTree),
+		paths:  make(map[string][]*path),
+	}
+
+	hosts := make(map[string]*path)
+	for i, routeUrl := range routeUrls {
 		p, err := initPath(routeUrl.url)
 		if err != nil {
-			return errors.Wrapf(err, "restRouter url error: %s.", routeUrl.url)
+			return nil, errors.Wrapf(err, "restRouter url error: %s.", routeUrl.url)
+		}
+		p.seq = i
+		p.version = routeUrl.version
+
+		if routeUrl.host != "" {
+			host, ok := hosts[routeUrl.host]
+			if !ok {
+				if host, err = initHost(routeUrl.host); err != nil {
+					return nil, errors.Wrapf(err, "restRouter host error: %s.", routeUrl.host)
+				}
+				hosts[routeUrl.host] = host
+			}
+			p.host = host
 		}
+		p.initResult()
 
 		methods := routeUrl.methods
 		if len(methods) == 0 {
@@ -88,55 +274,121 @@ func (router *restRouter) Start() errors.Error {
 		}
 
 		for _, method := range methods {
-			pathMap, ok := urlMapping[method]
-			if !ok {
-				pathMap = make(map[int][]*path)
-				urlMapping[method] = pathMap
+			tree := findHostTree(t.trees[method], p.host)
+			if tree == nil {
+				tree = &hostTree{host: p.host, root: newNode(nil)}
+				t.trees[method] = append(t.trees[method], tree)
+			}
+			tree.root.add(p, policy.Case != Strict)
+			for _, v := range p.variants() {
+				tree.root.add(v, policy.Case != Strict)
 			}
+			t.paths[method] = append(t.paths[method], p)
+		}
+	}
 
-			pathArr, _ := pathMap[p.depth]
-			pathMap[p.depth] = append(pathArr, p)
+	for method, hostTrees := range t.trees {
+		sort.Stable(sortedHostTrees(hostTrees))
+		for _, tree := range hostTrees {
+			t.conflicts = append(t.conflicts, tree.conflicts(method)...)
 		}
 	}
+	sort.Sort(sortedConflicts(t.conflicts))
+	return t, nil
+}
+
+func (router *restRouter) Conflicts() []Conflict {
+	return router.load().Conflicts()
+}
+
+func (t *table) Conflicts() []Conflict {
+	if t == nil {
+		return nil
+	}
+	return t.conflicts
+}
 
-	for _, v := range urlMapping {
-		for _, paths := range v {
-			sortPaths(paths)
+func findHostTree(trees []*hostTree, host *path) *hostTree {
+	for _, tree := range trees {
+		if tree.host == host {
+			return tree
 		}
 	}
-	router.urlMapping = urlMapping
 	return nil
 }
 
 func (router *restRouter) Route(method string, url string) *Result {
+	return router.RouteHost(method, "", url)
+}
 
-	routes := router.urlMapping[method]
-	if routes == nil {
-		return &Result{}
-	}
+func (router *restRouter) RouteHost(method string, host string, url string) *Result {
+	return router.RouteVersion(method, host, "", url)
+}
 
-	var target *path
-	strs := splitTrim(url, pathSep)
-	depth := len(strs)
+func (router *restRouter) RouteVersion(method string, host string, version string, url string) *Result {
+	return router.load().RouteVersion(method, host, version, url)
+}
 
-	for depth >= 0 {
-		if paths := routes[depth]; paths != nil {
-			for _, p := range paths {
-				if p.match(strs) {
-					target = p
-					break
-				}
-			}
-			if target != nil {
-				break
-			}
-		}
-		depth--
+// Route on the table, nil before start matches nothing.
+func (t *table) RouteVersion(method string, host string, version string, url string) *Result {
+	if t == nil || (t.policy.DuplicateSlash == Strict && hasDuplicateSlash(url)) {
+		return noMatch
 	}
 
+	host = normalizeHost(host)
+	target := t.lookup(t.trees[method], host, url, version)
 	if target == nil {
-		return &Result{}
+		if allow := t.allow(method, host, url, version); allow != nil {
+			return &Result{Allow: allow}
+		}
+		return noMatch
+	}
+
+	if t.policy.redirect() && t.policy.needRedirect(target, url) {
+		return &Result{Url: target.origin, Redirect: t.policy.canonical(target, url)}
+	}
+
+	if target.result != nil {
+		return target.result
 	}
 
-	return &Result{IsMatch: true, Url: target.origin, pieces: strs, path: target}
+	result := &Result{IsMatch: true, Url: target.origin, Version: target.version, reqUrl: url, reqHost: host, path: target}
+	if target.host != nil {
+		result.Host = target.host.origin
+	}
+	return result
+}
+
+// Find the path matching the host, url and version in the tries, by the policy.
+func (t *table) lookup(trees []*hostTree, host string, url string, version string) *path {
+	slash := hasSlash(url)
+	for _, tree := range trees {
+		if !tree.match(host) {
+			continue
+		}
+
+		p := tree.root.match(url, 0, slash, t.policy.Case != Strict, version)
+		if p != nil && t.policy.TrailingSlash == Strict && p.slash != slash {
+			p = nil
+		}
+		if p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// Find the methods, except the given one, under which the url matches.
+func (t *table) allow(method string, host string, url string, version string) []string {
+	var methods []string
+	for m, trees := range t.trees {
+		if m == method || m == "" {
+			continue
+		}
+		if t.lookup(trees, host, url, version) != nil {
+			methods = append(methods, m)
+		}
+	}
+	sort.Strings(methods)
+	return methods
 }
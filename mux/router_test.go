@@ -4,6 +4,8 @@ package mux
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
 	"testing"
 )
 
@@ -57,9 +59,9 @@ func TestRouterRoute(t *testing.T) {
 	result3 := router.Route("HEAD", "/")
 	assertFalse(result3.Url == "/", "case3", t)
 	result4 := router.Route("POST", "/profile")
-	assertTrue(result4.Url == "/", "case4", t)
+	assertFalse(result4.IsMatch, "case4", t)
 	result5 := router.Route("GET", "/profile")
-	assertTrue(result5.Url == "/", "case5", t)
+	assertFalse(result5.IsMatch, "case5", t)
 	result6 := router.Route("GET", "")
 	assertTrue(result6.Url == "/", "case6", t)
 
@@ -69,7 +71,7 @@ func TestRouterRoute(t *testing.T) {
 	result8 := router.Route("POST", "/home")
 	assertTrue(result8.Url == "/home/", "case8", t)
 	result9 := router.Route("POST", "/home/abc/xyz")
-	assertTrue(result9.Url == `/home/(all:^[a-z]*$)`, "case9", t)
+	assertFalse(result9.IsMatch, "case9", t)
 
 	// /home/profile1
 	result10 := router.Route("POST", "/home/profile1")
@@ -107,3 +109,296 @@ func TestRouterRoute(t *testing.T) {
 	assertTrue(result16.Url == `/home/profile1/view`, "case16", t)
 	assertTrue(result16.Parse() == nil, "case16", t)
 }
+
+func TestRouterCatchAll(t *testing.T) {
+	router := New("catchRouter")
+	router.Add([]string{"GET"}, "/static/(*file)")
+	router.Add([]string{"GET"}, "/static/(dir)/index")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	result1 := router.Route("GET", "/static/css/main.css")
+	assertTrue(result1.Url == "/static/(*file)", "case1", t)
+	arr1, _ := result1.Parse()["file"]
+	assertTrue(len(arr1) == 1 && arr1[0] == "css/main.css", "case1", t)
+
+	result2 := router.Route("GET", "/static/css/index")
+	assertTrue(result2.Url == "/static/(dir)/index", "case2", t)
+
+	result3 := router.Route("GET", "/static")
+	assertFalse(result3.IsMatch, "case3", t)
+}
+
+func TestRouterPolicy(t *testing.T) {
+	newRouter := func(policy Policy) Router {
+		router := New("policyRouter")
+		router.SetPolicy(policy)
+		router.Add([]string{"GET"}, "/Home/profile")
+		router.Add([]string{"GET"}, "/user/(id)/")
+		if err := router.Start(); err != nil {
+			t.FailNow()
+		}
+		return router
+	}
+
+	lenient := newRouter(Policy{})
+	assertTrue(lenient.Route("GET", "/home//profile/").IsMatch, "case1", t)
+	assertTrue(lenient.Route("GET", "/user/1").IsMatch, "case1", t)
+
+	strict := newRouter(Policy{Strict, Strict, Strict})
+	assertTrue(strict.Route("GET", "/Home/profile").IsMatch, "case2", t)
+	assertFalse(strict.Route("GET", "/Home/profile/").IsMatch, "case2", t)
+	assertFalse(strict.Route("GET", "/Home//profile").IsMatch, "case2", t)
+	assertFalse(strict.Route("GET", "/home/profile").IsMatch, "case2", t)
+	assertFalse(strict.Route("GET", "/user/1").IsMatch, "case2", t)
+
+	redirect := newRouter(Policy{Redirect, Redirect, Redirect})
+	assertTrue(redirect.Route("GET", "/Home/profile").IsMatch, "case3", t)
+	assertTrue(redirect.Route("GET", "/home//profile/").Redirect == "/Home/profile", "case3", t)
+	assertTrue(redirect.Route("GET", "/user/1").Redirect == "/user/1/", "case3", t)
+}
+
+func TestRouterHost(t *testing.T) {
+	router := New("hostRouter")
+	router.AddHost([]string{"GET"}, "api.example.com", "/user/(id)")
+	router.AddHost([]string{"GET"}, "(tenant).example.com", "/user/(id)")
+	router.Add([]string{"GET"}, "/user/(id)")
+	router.AddHost([]string{"GET"}, "www.example.com", "/about")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	result1 := router.RouteHost("GET", "API.example.com:8080", "/user/1")
+	assertTrue(result1.Host == "api.example.com", "case1", t)
+	assertTrue(reflect.DeepEqual(result1.Parse(), url.Values{"id": {"1"}}), "case1", t)
+
+	result2 := router.RouteHost("GET", "acme.example.com", "/user/1")
+	assertTrue(result2.Host == "(tenant).example.com", "case2", t)
+	assertTrue(reflect.DeepEqual(result2.Parse(), url.Values{"id": {"1"}, "tenant": {"acme"}}), "case2", t)
+
+	result3 := router.RouteHost("GET", "localhost", "/user/1")
+	assertTrue(result3.IsMatch && result3.Host == "", "case3", t)
+
+	result4 := router.RouteHost("GET", "api.example.com", "/about")
+	assertFalse(result4.IsMatch, "case4", t)
+	result5 := router.RouteHost("GET", "www.example.com", "/about")
+	assertTrue(result5.IsMatch && result5.Host == "www.example.com", "case5", t)
+}
+
+func TestRouterVersion(t *testing.T) {
+	router := New("versionRouter")
+	router.AddVersion([]string{"GET"}, "", "1", "/user/(id)")
+	router.AddVersion([]string{"GET"}, "", "10", "/user/(id)")
+	router.AddVersion([]string{"GET"}, "", "2", "/user/(uid)")
+	router.AddVersion([]string{"GET"}, "", "2", "/user/(id:int)")
+	router.Add([]string{"GET"}, "/about")
+	router.AddVersion([]string{"GET"}, "", "2", "/about")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	result1 := router.RouteVersion("GET", "", "1", "/user/3")
+	assertTrue(result1.Version == "1" && result1.Url == "/user/(id)", "case1", t)
+	result2 := router.RouteVersion("GET", "", "2", "/user/3")
+	assertTrue(result2.Version == "2" && result2.Url == "/user/(id:int)", "case2", t)
+	result3 := router.RouteVersion("GET", "", "2", "/user/abc")
+	assertTrue(result3.Version == "2" && result3.Url == "/user/(uid)", "case3", t)
+
+	// Fall back to the latest version without the default one.
+	result4 := router.Route("GET", "/user/abc")
+	assertTrue(result4.Version == "10", "case4", t)
+	result5 := router.RouteVersion("GET", "", "3", "/user/abc")
+	assertTrue(result5.Version == "10", "case5", t)
+
+	// Fall back to the default version.
+	result6 := router.Route("GET", "/about")
+	assertTrue(result6.IsMatch && result6.Version == "", "case6", t)
+	result7 := router.RouteVersion("GET", "", "2", "/about")
+	assertTrue(result7.IsMatch && result7.Version == "2", "case7", t)
+
+	assertTrue(len(router.Conflicts()) == 0, "case8", t)
+	router.Remove([]string{"GET"}, "", "2", "/about")
+	router.Start()
+	assertTrue(router.RouteVersion("GET", "", "2", "/about").Version == "", "case9", t)
+}
+
+func TestRouterOptional(t *testing.T) {
+	router := New("optionalRouter")
+	router.Add([]string{"GET"}, "/list/(page?=1)/(size?)")
+	router.Add([]string{"GET"}, "/archive/(year)-(month)")
+	router.Add([]string{"GET"}, "/archive/(name)")
+	router.Add([]string{"GET"}, "/top")
+	router.Add([]string{"GET"}, "/top/(n?=10)")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	result1 := router.Route("GET", "/list")
+	assertTrue(reflect.DeepEqual(result1.Parse(), url.Values{"page": {"1"}}), "case1", t)
+	result2 := router.Route("GET", "/list/3")
+	assertTrue(reflect.DeepEqual(result2.Parse(), url.Values{"page": {"3"}}), "case2", t)
+	result3 := router.Route("GET", "/list/3/20")
+	assertTrue(reflect.DeepEqual(result3.Parse(), url.Values{"page": {"3"}, "size": {"20"}}), "case3", t)
+
+	result4 := router.Route("GET", "/archive/2014-02")
+	assertTrue(result4.Url == "/archive/(year)-(month)", "case4", t)
+	result5 := router.Route("GET", "/archive/all")
+	assertTrue(result5.Url == "/archive/(name)", "case5", t)
+
+	result6 := router.Route("GET", "/top")
+	assertTrue(result6.Url == "/top" && result6.Parse() == nil, "case6", t)
+	assertTrue(len(router.Conflicts()) == 0, "case6", t)
+}
+
+func TestRouterOptionalHostVersion(t *testing.T) {
+	router := New("optionalHostRouter")
+	router.AddHost([]string{"GET"}, "api.example.com", "/hl/(page?)")
+	router.AddVersion([]string{"GET"}, "", "2", "/vl/(page?)")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	result1 := router.RouteHost("GET", "api.example.com", "/hl")
+	assertTrue(result1.IsMatch && result1.Host == "api.example.com", "case1", t)
+	result2 := router.RouteHost("GET", "api.example.com", "/hl/3")
+	assertTrue(result2.IsMatch && result2.Host == "api.example.com", "case2", t)
+	result3 := router.RouteVersion("GET", "", "2", "/vl")
+	assertTrue(result3.IsMatch && result3.Version == "2", "case3", t)
+}
+
+func TestRouterConflicts(t *testing.T) {
+	router := New("conflictRouter")
+	router.Add([]string{"GET"}, "/a/(x)")
+	router.Add([]string{"GET"}, "/a/(y)")
+	router.Add([]string{"GET"}, "/b/page(x)")
+	router.Add([]string{"GET"}, "/b/pa(y)")
+	router.Add([]string{"GET"}, "/b/user(z)")
+	router.Add([]string{"POST"}, "/a/(y)")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	conflicts := router.Conflicts()
+	assertTrue(len(conflicts) == 2, "case1", t)
+	assertTrue(conflicts[0].String() == "GET /a/(y) is unreachable, shadowed by /a/(x)", "case1", t)
+	assertTrue(conflicts[1].String() == "GET /b/pa(y) is ambiguous with /b/page(x)", "case1", t)
+}
+
+func TestRouterExplain(t *testing.T) {
+	router := New("explainRouter")
+	router.Add([]string{"GET"}, "/home/(id)")
+	router.Add([]string{"GET"}, "/home/profile")
+	router.Add([]string{"GET"}, "/home/(id)/view")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	traces := router.Explain("GET", "", "/home/profile")
+	assertTrue(len(traces) == 3, "case1", t)
+	assertTrue(traces[0].Matched && !traces[0].Selected && traces[0].Reason == "shadowed by /home/profile", "case1", t)
+	assertTrue(traces[1].Selected, "case1", t)
+	assertTrue(!traces[2].Matched && traces[2].Reason == "3 pieces expected, got 2", "case1", t)
+}
+
+func TestRouterAllow(t *testing.T) {
+	router := New("allowRouter")
+	router.Add([]string{"GET"}, "/user/(id)")
+	router.Add([]string{"PUT", "DELETE"}, "/user/(id)")
+	router.Add([]string{"POST"}, "/account")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	result1 := router.Route("POST", "/user/1")
+	assertFalse(result1.IsMatch, "case1", t)
+	assertTrue(reflect.DeepEqual(result1.Allow, []string{"DELETE", "GET", "PUT"}), "case1", t)
+
+	result2 := router.Route("GET", "/user/1")
+	assertTrue(result2.IsMatch && result2.Allow == nil, "case2", t)
+
+	result3 := router.Route("GET", "/")
+	assertFalse(result3.IsMatch, "case3", t)
+	assertTrue(len(result3.Allow) == 0, "case3", t)
+}
+
+func TestRouterAllocs(t *testing.T) {
+	router := New("allocRouter")
+	router.Add([]string{"GET"}, "/home/profile1")
+	router.Add([]string{"GET"}, `/home/profile(id:^[1-9]*$)`)
+	router.Add([]string{"GET"}, "/home/(all)")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	allocs1 := testing.AllocsPerRun(100, func() { router.Route("GET", "/home/profile1") })
+	assertTrue(allocs1 == 0, "case1", t)
+
+	allocs2 := testing.AllocsPerRun(100, func() { router.Route("GET", "/home/profile12") })
+	assertTrue(allocs2 <= 1, "case2", t)
+
+	allocs3 := testing.AllocsPerRun(100, func() { router.Route("GET", "/other") })
+	assertTrue(allocs3 == 0, "case3", t)
+}
+
+func BenchmarkRouterRoute(b *testing.B) {
+	router := New("benchRouter")
+	for i := 0; i < 100; i++ {
+		router.Add([]string{"GET", "POST"}, fmt.Sprintf("/module%d/(id)", i))
+		router.Add([]string{"GET"}, fmt.Sprintf("/module%d/(id)/page(num:^[0-9]+$)", i))
+		router.Add([]string{"GET"}, fmt.Sprintf("/module%d/list", i))
+	}
+	router.Start()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.Route("GET", "/module99/list")
+		router.Route("GET", "/module50/123/page2")
+	}
+}
+
+func BenchmarkRouterParse(b *testing.B) {
+	router := New("benchParseRouter")
+	router.Add([]string{"GET"}, "/users/(id:int)/posts/(slug)")
+	router.Add([]string{"GET"}, "/static/(*file)")
+	router.Start()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.Route("GET", "/users/123/posts/hello").Parse()
+	}
+}
+
+func TestRouterBuild(t *testing.T) {
+	router := New("buildRouter")
+	router.Add([]string{"GET"}, "/a/(x)")
+	router.Add([]string{"GET"}, "/a/(y)")
+
+	table, err := router.Build()
+	if err != nil {
+		t.FailNow()
+	}
+	assertTrue(table.RouteVersion("GET", "", "", "/a/1").Url == "/a/(x)", "case1", t)
+	assertTrue(len(table.Conflicts()) == 1, "case1", t)
+	assertTrue(!router.Route("GET", "/a/1").IsMatch, "case2", t)
+}
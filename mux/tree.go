@@ -0,0 +1,162 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"strings"
+)
+
+// Node of the routing trie, one trie for each method.
+// Precise pieces are looked up in statics, the other pieces are
+// kept in children ordered by priority from high to low.
+// Pieces of the same kind (same priority, prefix, suffix and regex)
+// share one node, the param names are resolved by the matched path.
+type node struct {
+	piece    *piece           // Representative piece, nil for root
+	statics  map[string]*node // Children of precise pieces
+	children []*node          // Children of the other pieces
+	paths    []*path          // Paths ending at this node, in adding order
+	latest   string           // The latest version of the paths
+}
+
+func newNode(p *piece) *node {
+	return &node{piece: p, statics: make(map[string]*node)}
+}
+
+// Add the path to the trie rooted at this node.
+// The precise pieces are folded to lower case if fold is true.
+func (n *node) add(p *path, fold bool) {
+	cur := n
+	for _, pc := range p.pieces {
+		cur = cur.child(pc, fold)
+	}
+	cur.paths = append(cur.paths, p)
+	if versionLess(cur.latest, p.version) {
+		cur.latest = p.version
+	}
+}
+
+// Get or create the child for the piece.
+func (n *node) child(p *piece, fold bool) *node {
+	if p.prio == preciseM {
+		key := p.name
+		if fold {
+			key = strings.ToLower(key)
+		}
+		c, ok := n.statics[key]
+		if !ok {
+			c = newNode(p)
+			n.statics[key] = c
+		}
+		return c
+	}
+
+	i := 0
+	for ; i < len(n.children); i++ {
+		c := n.children[i]
+		if c.piece.sameKind(p) {
+			return c
+		}
+		if c.piece.prio < p.prio {
+			break
+		}
+	}
+
+	// Insert after the children with the same or higher priority.
+	c := newNode(p)
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = c
+	return c
+}
+
+// Find the path matching the url from pos, with the deepest depth
+// and the highest priority in the meaning of path.compare.
+// Paths with the same priority resolve in adding order.
+// Slash tells whether the url ends with slash, fold tells whether
+// the precise pieces are folded to lower case, version is the requested
+// version, empty for the default.
+func (n *node) match(url string, pos int, slash bool, fold bool, version string) *path {
+	seg, next, ok := nextSegment(url, pos)
+	if !ok {
+		return n.terminal(slash, version)
+	}
+
+	var best *path
+	if fold {
+		seg = strings.ToLower(seg)
+	}
+	if c, ok := n.statics[seg]; ok {
+		best = better(best, c.match(url, next, slash, fold, version), version)
+	}
+	for _, c := range n.children {
+		switch {
+		case c.piece.prio == catchM:
+			// Catch-all ends the path, matching all the rest pieces.
+			best = better(best, c.terminal(slash, version), version)
+		case c.piece.match(seg):
+			best = better(best, c.match(url, next, slash, fold, version), version)
+		}
+	}
+	return best
+}
+
+// The path ending at this node, prefer the one of the version by versionRank,
+// then the one with the same trailing slash, then the one defined over
+// the one omitting optional pieces.
+func (n *node) terminal(slash bool, version string) *path {
+	var best *path
+	bestScore := -1
+	for _, p := range n.paths {
+		score := versionRank(p, version, n.latest) * 4
+		if p.slash == slash {
+			score += 2
+		}
+		if !p.variant {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best
+}
+
+// Return the one of the requested version, then the one with higher priority,
+// then the former.
+func better(p *path, other *path, version string) *path {
+	if other == nil {
+		return p
+	}
+	if p == nil {
+		return other
+	}
+	if version != "" && (p.version == version) != (other.version == version) {
+		if other.version == version {
+			return other
+		}
+		return p
+	}
+	if other.compare(p) > 0 {
+		return other
+	}
+	return p
+}
+
+// Scan the next non-empty trimmed segment of the url from pos,
+// returns the segment and the position after it.
+func nextSegment(url string, pos int) (string, int, bool) {
+	for pos < len(url) {
+		end := len(url)
+		if i := strings.Index(url[pos:], pathSep); i != -1 {
+			end = pos + i
+		}
+
+		seg := strings.TrimSpace(url[pos:end])
+		pos = end + 1
+		if seg != "" {
+			return seg, pos, true
+		}
+	}
+	return "", pos, false
+}
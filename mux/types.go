@@ -0,0 +1,61 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Named pattern of the param pieces, such as "(id:int)" for "(id:^-?[0-9]+$)".
+// Check verifies the value beyond the regex, such as the range of int, nil for none.
+type paramType struct {
+	name  string
+	regex *regexp.Regexp
+	check func(str string) bool
+}
+
+// Guards paramTypes, aliases may be registered while routers build.
+var typesMu sync.RWMutex
+
+var paramTypes = map[string]*paramType{
+	"int": {name: "int", regex: regexp.MustCompile(`^-?[0-9]+$`), check: func(str string) bool {
+		_, err := strconv.ParseInt(str, 10, 64)
+		return err == nil
+	}},
+	"slug": {name: "slug", regex: regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)},
+	"uuid": {name: "uuid", regex: regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)},
+	"date": {name: "date", regex: regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`), check: func(str string) bool {
+		_, err := time.Parse("2006-01-02", str)
+		return err == nil
+	}},
+}
+
+// Alias registers a named pattern for the param pieces, eg. Alias("hex", "^[0-9a-f]+$")
+// then "(color:hex)" matches "ff00ff". Built-in names are "int", "slug", "uuid" and "date".
+// Register aliases before the routes using them are built, it is safe to call while
+// other routers start. It panics if the name is registered or the expression is bad.
+func Alias(name string, expr string) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	if _, dup := paramTypes[name]; dup {
+		panic(fmt.Sprintf("light/mux: Alias %s is registered.", name))
+	}
+
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		panic(fmt.Sprintf("light/mux: Bad expression of alias %s. %v", name, err))
+	}
+	paramTypes[name] = &paramType{name: name, regex: regex}
+}
+
+// Get the named pattern, nil if not registered.
+func lookupType(name string) *paramType {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	return paramTypes[name]
+}
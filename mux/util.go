@@ -41,3 +41,12 @@ func (a sortedPaths) Less(i, j int) bool {
 func sortPaths(paths []*path) {
 	sort.Sort(sortedPaths(paths))
 }
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 li. All rights reserved.
+
+package mux
+
+import (
+	"strconv"
+	"strings"
+)
+
+const versionSep = "."
+
+// Rank of the path for the requested version at the same node.
+// The path of the requested version ranks first, then the one without version
+// as the default, then the one of the latest version.
+func versionRank(p *path, version string, latest string) int {
+	switch {
+	case version != "" && p.version == version:
+		return 3
+	case p.version == "":
+		return 2
+	case p.version == latest:
+		return 1
+	}
+	return 0
+}
+
+// Is version a lower than b. Versions are compared by the pieces separated by ".",
+// numerically if both pieces are numbers, such as "2" < "10" and "1.2" < "1.10".
+func versionLess(a string, b string) bool {
+	as := strings.Split(a, versionSep)
+	bs := strings.Split(b, versionSep)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
@@ -28,6 +28,7 @@ func New(config conf.Config) (*Manager, errors.Error) {
 	manager := &Manager{
 		store:  store,
 		config: innerConfig}
+	manager.SetSecure(innerConfig.Secure)
 	manager.start()
 
 	return manager, nil
@@ -0,0 +1,117 @@
+// Copyright 2014 li. All rights reserved.
+
+package session
+
+import (
+	"github.com/roverli/light/log"
+	"net/http"
+)
+
+var _ Session = &lazySession{}
+
+// Lazy returns a session resolved on first use.
+// Reading attributes loads the existing session only, so requests never
+// writing the session create neither the session nor the cookies.
+// Writing attributes or reading the session identity creates the session if none,
+// which must happen before the response is written to get the cookies sent.
+func (m *Manager) Lazy(r *http.Request, w http.ResponseWriter) Session {
+	return &lazySession{manager: m, r: r, w: w}
+}
+
+type lazySession struct {
+	manager *Manager
+	r       *http.Request
+	w       http.ResponseWriter
+	session Session // Resolved session, nil if not exists
+	loaded  bool    // If tried to load the existing session
+}
+
+// Load the existing session, nil if none.
+func (s *lazySession) load() Session {
+	if !s.loaded {
+		s.loaded = true
+
+		session, err := s.manager.Get(s.r)
+		if err != nil {
+			log.Errorf("light/session: Get session error. %v", err)
+		}
+		s.session = session
+	}
+	return s.session
+}
+
+// Load the existing session, or create one.
+func (s *lazySession) create() Session {
+	if s.load() == nil {
+		session, err := s.manager.Create(s.r, s.w)
+		if err != nil {
+			log.Errorf("light/session: Create session error. %v", err)
+			return nilSession{}
+		}
+		s.session = session
+	}
+	return s.session
+}
+
+func (s *lazySession) Id() string {
+	return s.create().Id()
+}
+
+func (s *lazySession) CreationTime() int64 {
+	return s.create().CreationTime()
+}
+
+func (s *lazySession) LastAccessedTime() int64 {
+	return s.create().LastAccessedTime()
+}
+
+func (s *lazySession) GetAttribute(name string) interface{} {
+	if session := s.load(); session != nil {
+		return session.GetAttribute(name)
+	}
+	return nil
+}
+
+func (s *lazySession) GetAttributeNames() []string {
+	if session := s.load(); session != nil {
+		return session.GetAttributeNames()
+	}
+	return []string{}
+}
+
+func (s *lazySession) SetAttribute(name string, value interface{}) {
+	if value == nil {
+		s.RemoveAttribute(name)
+		return
+	}
+	s.create().SetAttribute(name, value)
+}
+
+func (s *lazySession) RemoveAttribute(name string) {
+	if session := s.load(); session != nil {
+		session.RemoveAttribute(name)
+	}
+}
+
+func (s *lazySession) Invalidate() {
+	if session := s.load(); session != nil {
+		session.Invalidate()
+	}
+}
+
+func (s *lazySession) IsNew() bool {
+	return s.create().IsNew()
+}
+
+// Session used when creating failed, holds nothing.
+type nilSession struct{}
+
+func (nilSession) Id() string                                  { return "" }
+func (nilSession) CreationTime() int64                         { return 0 }
+func (nilSession) LastAccessedTime() int64                     { return 0 }
+func (nilSession) GetAttribute(name string) interface{}        { return nil }
+func (nilSession) GetAttributeNames() []string                 { return []string{} }
+func (nilSession) SetAttribute(name string, value interface{}) {}
+func (nilSession) RemoveAttribute(name string)                 {}
+func (nilSession) Invalidate()                                 {}
+func (nilSession) IsNew() bool                                 { return true }
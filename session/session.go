@@ -103,6 +103,7 @@ type Manager struct {
 	ticker   *time.Ticker
 	store    Store
 	config   Config
+	secure   int32 // Secure flag of the cookies, 1 for true, see SetSecure
 }
 
 // Retrieve a session from context by http request.
@@ -143,8 +144,8 @@ func (m *Manager) Create(r *http.Request, w http.ResponseWriter) (Session, error
 		return nil, ErrGenSessionId
 	}
 
-	cookie1 := m.newCookie(id1)
-	cookie2 := m.newCookie(id2)
+	cookie1 := m.newCookie(m.config.CookieName+"1", id1)
+	cookie2 := m.newCookie(m.config.CookieName+"2", id2)
 
 	if m.config.EnableCookie {
 		http.SetCookie(w, cookie1)
@@ -156,17 +157,27 @@ func (m *Manager) Create(r *http.Request, w http.ResponseWriter) (Session, error
 	return m.store.New(id1 + m.config.Sed + id2)
 }
 
-func (m *Manager) newCookie(id string) *http.Cookie {
+func (m *Manager) newCookie(name string, id string) *http.Cookie {
 	return &http.Cookie{
-		Name:     m.config.CookieName,
+		Name:     name,
 		Value:    url.QueryEscape(id),
 		Path:     "/",
 		HttpOnly: m.config.HttpOnly,
-		Secure:   m.config.Secure,
+		Secure:   atomic.LoadInt32(&m.secure) == 1,
 		MaxAge:   m.config.MaxAge,
 		Domain:   m.config.Domain}
 }
 
+// SetSecure sets the Secure flag of the session cookies, overriding the config "secure".
+// The config is kept as it is.
+func (m *Manager) SetSecure(secure bool) {
+	var flag int32
+	if secure {
+		flag = 1
+	}
+	atomic.StoreInt32(&m.secure, flag)
+}
+
 // Persist session to the underlying store.
 func (m *Manager) Save(s Session) errors.Error {
 	if atomic.LoadInt32(&m.isClosed) == 1 {
@@ -0,0 +1,116 @@
+// Copyright 2014 li. All rights reserved.
+
+package light
+
+import (
+	"crypto/tls"
+	"github.com/roverli/light/log"
+	"github.com/roverli/utils/errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Create the tls config by "httpSslMinVersion" and "httpSslCiphers".
+// The certificate is reloaded on SIGHUP.
+func newTLSConfig() (*tls.Config, errors.Error) {
+	config, err := parseTLSConfig(HttpSslMinVersion, HttpSslCiphers)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := &certLoader{certFile: HttpSslCert, keyFile: HttpSslKey}
+	if err := loader.load(); err != nil {
+		return nil, err
+	}
+	config.GetCertificate = loader.getCertificate
+
+	// Watch only if the config is valid, so the goroutine never leaks.
+	go loader.watch()
+	return config, nil
+}
+
+// Create the tls config of the min version, such as "1.2",
+// and the comma separated cipher suite names, empty for go defaults.
+func parseTLSConfig(minVersion string, ciphers string) (*tls.Config, errors.Error) {
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, errors.Newf("Unknown tls version %s.", minVersion)
+	}
+	config := &tls.Config{MinVersion: version}
+
+	if ciphers != "" {
+		suites := make(map[string]uint16)
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+
+		for _, name := range strings.Split(ciphers, ",") {
+			id, ok := suites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, errors.Newf("Unknown or insecure cipher suite %s.", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+	return config, nil
+}
+
+// Holds the certificate, reloads it on SIGHUP without restart.
+type certLoader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Value // *tls.Certificate
+}
+
+func (l *certLoader) load() errors.Error {
+	cert, err := tls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		return errors.Wrapf(err, "Load certificate %s error.", l.certFile)
+	}
+	l.cert.Store(&cert)
+	return nil
+}
+
+// Reload on SIGHUP, keep the current certificate if reloading fails.
+func (l *certLoader) watch() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if err := l.load(); err != nil {
+			log.Errorf("Reload certificate fail, keep the current one. %v\n", err)
+		} else {
+			log.Infof("Reload certificate %s.\n", l.certFile)
+		}
+	}
+}
+
+func (l *certLoader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return l.cert.Load().(*tls.Certificate), nil
+}
+
+// Redirect plain http requests to https.
+func redirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if HttpPort != "" && HttpPort != "443" {
+			host = net.JoinHostPort(host, HttpPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
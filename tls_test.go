@@ -0,0 +1,55 @@
+// Copyright 2014 li. All rights reserved.
+
+package light
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseTLSConfig(t *testing.T) {
+	config, err := parseTLSConfig("1.3", "")
+	if err != nil || config.MinVersion != tls.VersionTLS13 || config.CipherSuites != nil {
+		t.Errorf("unexpected config %v, error %v.", config, err)
+	}
+
+	config, err = parseTLSConfig("1.2", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+	expected := []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}
+	if err != nil || config.MinVersion != tls.VersionTLS12 || !reflect.DeepEqual(config.CipherSuites, expected) {
+		t.Errorf("unexpected config %v, error %v.", config, err)
+	}
+
+	if _, err = parseTLSConfig("2.0", ""); err == nil {
+		t.Error("expected unknown version error.")
+	}
+	// Insecure suites are not accepted.
+	if _, err = parseTLSConfig("1.2", "TLS_RSA_WITH_RC4_128_SHA"); err == nil {
+		t.Error("expected unknown cipher error.")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	httpPort := HttpPort
+	defer func() {
+		HttpPort = httpPort
+	}()
+
+	cases := []struct {
+		port, url, expected string
+	}{
+		{"443", "http://example.com/a?b=1", "https://example.com/a?b=1"},
+		{"", "http://example.com:8080/a", "https://example.com/a"},
+		{"8443", "http://example.com:8080/a", "https://example.com:8443/a"},
+		{"8443", "http://[::1]:8080/a", "https://[::1]:8443/a"},
+	}
+	for _, c := range cases {
+		HttpPort = c.port
+		resp := httptest.NewRecorder()
+		redirectHandler().ServeHTTP(resp, httptest.NewRequest("GET", c.url, nil))
+		if location := resp.Header().Get("Location"); resp.Code != 301 || location != c.expected {
+			t.Errorf("%s: expected 301 %s, got %d %s.", c.url, c.expected, resp.Code, location)
+		}
+	}
+}
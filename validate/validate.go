@@ -0,0 +1,190 @@
+// Copyright 2014 li. All rights reserved.
+
+// Package validate checks values by the rules declared in struct tags.
+// Rules are separated by comma, such as `@:"required,min=3,max=20,email"`.
+// The regex rule takes the rest of the tag, so put it the last if it contains comma.
+package validate
+
+import (
+	"github.com/roverli/light/util"
+	"github.com/roverli/utils/errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator checks the value with the rule argument,
+// returns the error describing why the value is invalid, such as "is too short".
+type Validator func(v reflect.Value, arg string) error
+
+// Creates the check function from the rule argument.
+type factory func(arg string) (func(v reflect.Value) error, error)
+
+var factories = map[string]factory{
+	"required": newRequired,
+	"min":      newMin,
+	"max":      newMax,
+	"regex":    newRegex,
+	"email":    newEmail,
+}
+
+var emailRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// Register the validator by name, used as `@:"name"` or `@:"name=arg"`.
+func Register(name string, validator Validator) {
+	util.PanicfIfTrue(factories[name] != nil, "light/validate: duplicate validator %s.", name)
+	factories[name] = func(arg string) (func(v reflect.Value) error, error) {
+		return func(v reflect.Value) error {
+			return validator(v, arg)
+		}, nil
+	}
+}
+
+// Rules are the compiled rules of a tag.
+type Rules struct {
+	required bool
+	checks   []func(v reflect.Value) error
+}
+
+// Compile the tag into rules.
+func Compile(tag string) (*Rules, errors.Error) {
+	rules := &Rules{}
+
+	for tag = strings.TrimSpace(tag); tag != ""; {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			rule, tag = tag[:i], strings.TrimSpace(tag[i+1:])
+		} else {
+			rule, tag = tag, ""
+		}
+
+		name, arg := strings.TrimSpace(rule), ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, arg = strings.TrimSpace(rule[:i]), strings.TrimSpace(rule[i+1:])
+		}
+
+		if name == "required" {
+			rules.required = true
+		}
+
+		f := factories[name]
+		if f == nil {
+			return nil, errors.Newf("light/validate: no such validator %s.", name)
+		}
+		check, err := f(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "light/validate: bad rule %s.", rule)
+		}
+		rules.checks = append(rules.checks, check)
+	}
+	return rules, nil
+}
+
+// Validate the value, returns the messages of the broken rules.
+// Zero values are only checked by "required", other rules are skipped.
+func (rules *Rules) Validate(v reflect.Value) []string {
+	if isZero(v) && !rules.required {
+		return nil
+	}
+
+	var msgs []string
+	for _, check := range rules.checks {
+		if err := check(v); err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return msgs
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// Get the size for min and max, length for strings and containers, the number for numbers.
+func size(v reflect.Value) (float64, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, true
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func newRequired(arg string) (func(v reflect.Value) error, error) {
+	return func(v reflect.Value) error {
+		if isZero(v) {
+			return errors.New("is required")
+		}
+		return nil
+	}, nil
+}
+
+func newMin(arg string) (func(v reflect.Value) error, error) {
+	min, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) error {
+		if n, ok := size(v); ok && n < min {
+			return errors.Newf("must be at least %s", arg)
+		}
+		return nil
+	}, nil
+}
+
+func newMax(arg string) (func(v reflect.Value) error, error) {
+	max, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) error {
+		if n, ok := size(v); ok && n > max {
+			return errors.Newf("must be at most %s", arg)
+		}
+		return nil
+	}, nil
+}
+
+func newRegex(arg string) (func(v reflect.Value) error, error) {
+	regex, err := regexp.Compile(arg)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) error {
+		if v.Kind() == reflect.String && !regex.MatchString(v.String()) {
+			return errors.Newf("must match %s", arg)
+		}
+		return nil
+	}, nil
+}
+
+func newEmail(arg string) (func(v reflect.Value) error, error) {
+	return func(v reflect.Value) error {
+		if v.Kind() == reflect.String && !emailRegex.MatchString(v.String()) {
+			return errors.New("must be an email address")
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 li. All rights reserved.
+
+package validate
+
+import (
+	"github.com/roverli/utils/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func validate(t *testing.T, tag string, v interface{}) []string {
+	rules, err := Compile(tag)
+	if err != nil {
+		t.Fatalf("compile %s error. %v", tag, err)
+	}
+	return rules.Validate(reflect.ValueOf(v))
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		tag   string
+		value interface{}
+		valid bool
+	}{
+		{"required", "", false},
+		{"required", "a", true},
+		{"required", []int{}, false},
+		{"min=3", "", true}, // Zero value is optional.
+		{"min=3", "ab", false},
+		{"min=3,max=5", "abc", true},
+		{"min=3,max=5", "abcdef", false},
+		{"max=2", "中文", true},
+		{"min=18", 17, false},
+		{"min=18", 18, true},
+		{"max=1.5", 1.6, false},
+		{"required,min=1", 0, false},
+		{"email", "li@example.com", true},
+		{"email", "li@example", false},
+		{"regex=^[a-z]+$", "abc", true},
+		{"regex=^[a-z]+$", "Abc", false},
+		{"min=1,regex=^[a-z]{1,3}$", "abcd", false},
+		{"min=1,regex=^[a-z]{1,3}$", "abc", true},
+	}
+
+	for _, c := range cases {
+		msgs := validate(t, c.tag, c.value)
+		if (len(msgs) == 0) != c.valid {
+			t.Errorf("tag: %s, value: %v, expected valid %v, got %v.", c.tag, c.value, c.valid, msgs)
+		}
+	}
+}
+
+func TestValidateMessages(t *testing.T) {
+	msgs := validate(t, "required,min=3,email", "ab")
+	if len(msgs) != 2 || msgs[0] != "must be at least 3" || msgs[1] != "must be an email address" {
+		t.Errorf("unexpected messages %v.", msgs)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("prefix", func(v reflect.Value, arg string) error {
+		if !strings.HasPrefix(v.String(), arg) {
+			return errors.Newf("must start with %s", arg)
+		}
+		return nil
+	})
+
+	if msgs := validate(t, "prefix=li", "lee"); len(msgs) != 1 || msgs[0] != "must start with li" {
+		t.Errorf("unexpected messages %v.", msgs)
+	}
+	if msgs := validate(t, "prefix=li", "light"); len(msgs) != 0 {
+		t.Errorf("unexpected messages %v.", msgs)
+	}
+}
+
+func TestCompileError(t *testing.T) {
+	for _, tag := range []string{"unknown", "min=abc", "regex=(["} {
+		if _, err := Compile(tag); err == nil {
+			t.Errorf("tag %s expected compile error.", tag)
+		}
+	}
+}
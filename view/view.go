@@ -34,7 +34,7 @@ var (
 	hasDefaultScreen bool
 )
 
-func Render(tpl string, data Context, wr io.Writer) errors.Error {
+func Render(tpl string, data interface{}, wr io.Writer) errors.Error {
 	t := tpls[tpl]
 	if t == nil {
 		return ErrViewNotFound
@@ -47,8 +47,50 @@ func Render(tpl string, data Context, wr io.Writer) errors.Error {
 	return nil
 }
 
+// Exists reports whether the view template is loaded.
+func Exists(tpl string) bool {
+	return tpls[tpl] != nil
+}
+
 type Context map[string]interface{}
 
+// URL builds the url of the named route for the "url" template function.
+// It's set by the web module.
+var URL = func(name string, params interface{}) (string, error) {
+	return "", errors.Newf("light/view: Can't build url of route %s.", name)
+}
+
+// Functions for all templates.
+var funcs = template.FuncMap{"url": urlFunc}
+
+// The "url" template function, the args is one map or struct,
+// or the key value pairs, such as {{url "user" "id" .Id}}.
+func urlFunc(name string, args ...interface{}) (string, error) {
+	switch {
+	case len(args) == 0:
+		return URL(name, nil)
+	case len(args) == 1:
+		return URL(name, args[0])
+	case len(args)%2 != 0:
+		return "", errors.Newf("light/view: Odd args of url %s.", name)
+	}
+
+	params := make(map[string]interface{}, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return "", errors.Newf("light/view: Param name of url %s must be string.", name)
+		}
+		params[key] = args[i+1]
+	}
+	return URL(name, params)
+}
+
+// Parse the template files with the template functions.
+func parseFiles(files ...string) (*template.Template, error) {
+	return template.New(filepath.Base(files[0])).Funcs(funcs).ParseFiles(files...)
+}
+
 func init() {
 	initScreen()
 	initView()
@@ -101,7 +143,7 @@ func initView() {
 			parseByScreen(name, "default")
 
 		default:
-			tpl, err := template.ParseFiles(conf.ROOT + DIR + name + SUFFIX)
+			tpl, err := parseFiles(conf.ROOT + DIR + name + SUFFIX)
 			switch err {
 			case nil:
 				log.Infof("light/view: Load view succeed, view: %s .", name)
@@ -123,15 +165,15 @@ func initView() {
 }
 
 func parseByScreen(name string, screen string) {
-	parseFiles := make([]string, len(screenFiles)+2, len(screenFiles)+2)
+	files := make([]string, len(screenFiles)+2, len(screenFiles)+2)
 	i := 1
 	found := false
 	for _, screenFile := range screenFiles {
 		if strings.HasSuffix(screenFile, "/"+screen+SUFFIX) {
-			parseFiles[0] = screenFile
+			files[0] = screenFile
 			found = true
 		} else {
-			parseFiles[i] = screenFile
+			files[i] = screenFile
 		}
 		i++
 	}
@@ -141,8 +183,8 @@ func parseByScreen(name string, screen string) {
 		return
 	}
 
-	parseFiles[i] = conf.ROOT + ScreenDIR + name + SUFFIX
-	tpl, err := template.ParseFiles(parseFiles...)
+	files[i] = conf.ROOT + ScreenDIR + name + SUFFIX
+	tpl, err := parseFiles(files...)
 	if err != nil {
 		log.Errorf("light/view: Parse template %s error.", name)
 		return
@@ -158,7 +200,7 @@ func readTplsHeader(fInfos []os.FileInfo, parent string) {
 		case finfo.IsDir():
 			childFInfos, err := listFile(parent + finfo.Name())
 			if err != nil {
-				log.Error("light/view: %v.", err)
+				log.Errorf("light/view: %v.", err)
 			} else {
 				readTplsHeader(childFInfos, parent+finfo.Name()+"/")
 			}
@@ -0,0 +1,153 @@
+// Copyright 2014 li. All rights reserved.
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/roverli/utils/errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Flatten the json object into form values, in the binder key syntax.
+// eg. {"user": {"name": "li", "tags": ["a", "b"]}} =>
+//   user.name=li, user[name]=li, user.tags[0]=a, user.tags[1]=b
+// Bodies other than object are kept in Params.Body only.
+func flattenJSON(body []byte) (url.Values, errors.Error) {
+	var v interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "light/web: Error decoding json body.")
+	}
+
+	values := make(url.Values)
+	if _, ok := v.(map[string]interface{}); ok {
+		flattenJSONValue(values, "", v)
+	}
+	return values, nil
+}
+
+func flattenJSONValue(values url.Values, key string, v interface{}) {
+	switch t := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, child := range t {
+			if key == "" {
+				flattenJSONValue(values, k, child)
+				continue
+			}
+			flattenJSONValue(values, key+"."+k, child)
+
+			// Scalar members also bind to map, see bindMap.
+			switch child.(type) {
+			case map[string]interface{}, []interface{}:
+			default:
+				flattenJSONValue(values, key+"["+k+"]", child)
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			flattenJSONValue(values, key+"["+strconv.Itoa(i)+"]", child)
+		}
+	case json.Number:
+		values.Add(key, t.String())
+	case string:
+		values.Add(key, t)
+	case bool:
+		values.Add(key, strconv.FormatBool(t))
+	}
+}
+
+// Xml element tree for flattening.
+type xmlNode struct {
+	name     string
+	text     bytes.Buffer
+	attrs    []xml.Attr
+	children []*xmlNode
+}
+
+// Flatten the children of the xml root element into form values, in the binder key syntax.
+// Repeated elements are indexed, attributes are keyed as children.
+// eg. <user id="1"><name>li</name><tag>a</tag><tag>b</tag></user> =>
+//   id=1, name=li, tag[0]=a, tag[1]=b
+func flattenXML(body []byte) (url.Values, errors.Error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+
+	var root *xmlNode
+	var stack []*xmlNode
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "light/web: Error decoding xml body.")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: t.Attr}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	values := make(url.Values)
+	if root != nil {
+		flattenXMLNode(values, "", root)
+	}
+	return values, nil
+}
+
+func flattenXMLNode(values url.Values, key string, node *xmlNode) {
+	prefix := key
+	if prefix != "" {
+		prefix += "."
+	}
+
+	for _, attr := range node.attrs {
+		values.Add(prefix+attr.Name.Local, attr.Value)
+	}
+
+	if len(node.children) == 0 {
+		if key != "" {
+			values.Add(key, strings.TrimSpace(node.text.String()))
+		}
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, child := range node.children {
+		counts[child.name]++
+	}
+
+	indexes := make(map[string]int)
+	for _, child := range node.children {
+		childKey := prefix + child.name
+		if counts[child.name] > 1 {
+			childKey += "[" + strconv.Itoa(indexes[child.name]) + "]"
+			indexes[child.name]++
+		}
+		flattenXMLNode(values, childKey, child)
+	}
+}
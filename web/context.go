@@ -5,6 +5,7 @@ package web
 import (
 	//"code.google.com/p/go.net/websocket"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/roverli/light/log"
 	"github.com/roverli/light/mux"
@@ -30,6 +31,7 @@ type Params struct {
 	Query      url.Values                         // Parameters from the query string, e.g. /list?page=2
 	Form       url.Values                         // Parameters from the request body.
 	Files      map[string][]*multipart.FileHeader // Files uploaded in a multipart form
+	Body       []byte                             // Raw json or xml request body
 	TmpFiles   []*os.File                         // Temp files used during the request.
 }
 
@@ -83,10 +85,15 @@ type Response struct {
 // Wrapper all.
 type Context struct {
 	Req         *http.Request       // Origin http request
+	Ctx         context.Context     // Request context, cancelled on client disconnect or route timeout
 	Resp        http.ResponseWriter // Origin http response writer
 	Params      *Params             // All request params
 	RouteResult *mux.Result         // The route result
+	RouteMethod string              // Method of the matched route, "GET" for HEAD served by GET
 	Session     session.Session     // Http Session
+	Format      string              // Format from url suffix, eg. "json" for "/users.json"
+	App         http.Handler        // The application serving the request
+	Serving     interface{}         // Routing table and chains of the app, loaded once for the request
 	//	Status      Status              // Handle status
 }
 
@@ -136,7 +143,7 @@ func ResolveAcceptLanguage(req *http.Request) AcceptLanguages {
 		if qualifiedRange := strings.Split(languageRange, ";q="); len(qualifiedRange) == 2 {
 			quality, error := strconv.ParseFloat(qualifiedRange[1], 32)
 			if error != nil {
-				log.Warn("Detected malformed Accept-Language header quality in '%s', assuming quality is 1", languageRange)
+				log.Warnf("Detected malformed Accept-Language header quality in '%s', assuming quality is 1", languageRange)
 				acceptLanguages[i] = AcceptLanguage{qualifiedRange[0], 1}
 			} else {
 				acceptLanguages[i] = AcceptLanguage{qualifiedRange[0], float32(quality)}
@@ -3,30 +3,143 @@
 package web
 
 import (
+	"bytes"
+	"github.com/roverli/light/conf"
 	"github.com/roverli/light/log"
+	"github.com/roverli/utils/errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 )
 
-func ParseParams(params *Params, r *http.Request) {
+var (
+	// Max bytes of the form, json and xml bodies parsed by the framework,
+	// configured by "httpMaxBodySize" in app.conf. Other bodies, such as
+	// application/octet-stream, are left to the handlers without limit.
+	MaxBodySize = int64(conf.App.Int("httpMaxBodySize", 1024*1024*10))
+
+	// Max bytes of the multipart body, configured by "httpMaxMultipartSize" in app.conf.
+	MaxMultipartSize = int64(conf.App.Int("httpMaxMultipartSize", 1024*1024*32))
+
+	ErrBodyTooLarge = errors.New("light/web: Request body too large.")
+)
+
+// Parse the query string and request body into params.
+// Json and xml bodies are flattened into form values, so they bind as forms do,
+// the request body is restored to be read again.
+func ParseParams(params *Params, r *http.Request) errors.Error {
 
 	params.Query = r.URL.Query()
+	defer func() {
+		params.Values = params.merge()
+	}()
+
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
+
+	switch contentType := ResolveContentType(r); contentType {
+	case "application/x-www-form-urlencoded":
+		if err := ParseForm(r); err != nil {
+			return err
+		}
+		params.Form = r.Form
+
+	case "multipart/form-data":
+		if err := ParseForm(r); err != nil {
+			return err
+		}
+		params.Form = r.MultipartForm.Value
+		params.Files = r.MultipartForm.File
+
+	case "application/json", "text/json",
+		"application/xml", "text/xml":
+		body, err := readBody(r)
+		if err != nil {
+			return err
+		}
+		params.Body = body
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		switch contentType {
+		case "application/json", "text/json":
+			params.Form, err = flattenJSON(body)
+		default:
+			params.Form, err = flattenXML(body)
+		}
+		if err != nil {
+			log.Warnf("light/web: Error parsing %s body. %v", contentType, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Parse the form body within MaxBodySize, or the multipart body within MaxMultipartSize,
+// does nothing for other bodies. It can be called several times, the body is parsed once.
+func ParseForm(r *http.Request) errors.Error {
+	if r.Body == nil || r.ContentLength == 0 {
+		return nil
+	}
 
 	switch ResolveContentType(r) {
 	case "application/x-www-form-urlencoded":
+		if r.PostForm == nil {
+			if r.ContentLength > MaxBodySize {
+				return ErrBodyTooLarge
+			}
+			r.Body = http.MaxBytesReader(nil, r.Body, MaxBodySize)
+		}
 		if err := r.ParseForm(); err != nil {
 			log.Warn("light/web: Error parsing request body.", err)
-		} else {
-			params.Form = r.Form
+			return bodyError(err, "light/web: Error parsing form body.")
 		}
 
 	case "multipart/form-data":
+		if r.MultipartForm == nil {
+			if r.ContentLength > MaxMultipartSize {
+				return ErrBodyTooLarge
+			}
+			r.Body = http.MaxBytesReader(nil, r.Body, MaxMultipartSize)
+		}
 		if err := r.ParseMultipartForm(1024 * 1024 * 10); err != nil {
-			log.Warn("light/web: : Error parsing request body.", err)
-		} else {
-			params.Form = r.MultipartForm.Value
-			params.Files = r.MultipartForm.File
+			log.Warn("light/web: Error parsing request body.", err)
+			return bodyError(err, "light/web: Error parsing multipart body.")
 		}
 	}
+	return nil
+}
 
-	params.Values = params.merge()
+// Read the body within MaxBodySize.
+func readBody(r *http.Request) ([]byte, errors.Error) {
+	if r.ContentLength > MaxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxBodySize+1))
+	switch {
+	case err != nil:
+		return nil, errors.Wrap(err, "light/web: Error reading request body.")
+	case int64(len(body)) > MaxBodySize:
+		return nil, ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+// Wrap the error of parsing the body, ErrBodyTooLarge if the body exceeds the limit.
+func bodyError(err error, msg string) errors.Error {
+	for e := err; e != nil; {
+		if _, ok := e.(*http.MaxBytesError); ok {
+			return ErrBodyTooLarge
+		}
+		u, ok := e.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	return errors.Wrap(err, msg)
 }
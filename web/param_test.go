@@ -0,0 +1,109 @@
+// Copyright 2014 li. All rights reserved.
+
+package web_test
+
+import (
+	"bytes"
+	"github.com/roverli/light/web"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newBodyRequest(contentType string, body string) *http.Request {
+	req, _ := http.NewRequest("POST", "http://localhost/path", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+func TestParseJSON(t *testing.T) {
+	body := `{"A": {"Id": 123, "Name": "rob"}, "arr": [1, 2], "nil": null}`
+	req := newBodyRequest("application/json; charset=utf-8", body)
+	params := &web.Params{}
+	if err := web.ParseParams(params, req); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"A.Id": "123", "A[Name]": "rob", "arr[1]": "2", "nil": ""}
+	for k, v := range expected {
+		if actual := params.Values.Get(k); actual != v {
+			t.Errorf("expected %s of %s, got %s.", v, k, actual)
+		}
+	}
+
+	if restored, _ := ioutil.ReadAll(req.Body); string(restored) != body {
+		t.Errorf("expected the body restored, got %s.", restored)
+	}
+}
+
+func TestParseXML(t *testing.T) {
+	body := `<req Id="7"><A><Id>123</Id></A><arr>1</arr><arr>2</arr></req>`
+	req := newBodyRequest("application/xml", body)
+	params := &web.Params{}
+	if err := web.ParseParams(params, req); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"Id": "7", "A.Id": "123", "arr[0]": "1", "arr[1]": "2"}
+	for k, v := range expected {
+		if actual := params.Values.Get(k); actual != v {
+			t.Errorf("expected %s of %s, got %s.", v, k, actual)
+		}
+	}
+
+	if restored, _ := ioutil.ReadAll(req.Body); string(restored) != body {
+		t.Errorf("expected the body restored, got %s.", restored)
+	}
+}
+
+func TestParseBodyTooLarge(t *testing.T) {
+	maxBodySize, maxMultipartSize := web.MaxBodySize, web.MaxMultipartSize
+	defer func() {
+		web.MaxBodySize, web.MaxMultipartSize = maxBodySize, maxMultipartSize
+	}()
+	web.MaxBodySize, web.MaxMultipartSize = 8, 1024
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("name", "a long enough value")
+	writer.Close()
+	multipartType := writer.FormDataContentType()
+
+	cases := []struct {
+		contentType string
+		body        string
+		unknownSize bool // Content-Length unknown, limited by reading
+		tooLarge    bool
+	}{
+		{"application/json", `{"A": {"Id": 123}}`, false, true},
+		{"application/json", `{"A": {"Id": 123}}`, true, true},
+		{"application/x-www-form-urlencoded", "name=a+long+value", false, true},
+		{"application/x-www-form-urlencoded", "name=a+long+value", true, true},
+		{"application/json", `{"A": 1}`, false, false},
+		{"application/octet-stream", "a long raw body", false, false},
+		{multipartType, buf.String(), false, false},
+		{multipartType, buf.String(), true, false},
+	}
+
+	for i, c := range cases {
+		req := newBodyRequest(c.contentType, c.body)
+		if c.unknownSize {
+			req.ContentLength = -1
+		}
+		err := web.ParseParams(&web.Params{}, req)
+		if tooLarge := err == web.ErrBodyTooLarge; tooLarge != c.tooLarge {
+			t.Errorf("case%d %s: expected too large %v, got error %v.", i, c.contentType, c.tooLarge, err)
+		}
+	}
+
+	web.MaxMultipartSize = 64
+	for _, size := range []int64{int64(buf.Len()), -1} {
+		req := newBodyRequest(multipartType, buf.String())
+		req.ContentLength = size
+		if err := web.ParseParams(&web.Params{}, req); err != web.ErrBodyTooLarge {
+			t.Errorf("expected multipart too large of size %d, got %v.", size, err)
+		}
+	}
+}
@@ -31,20 +31,37 @@ func ResolveFormat(req *http.Request) string {
 		return "html"
 
 	case strings.Contains(accept, "application/xml"),
-		strings.Contains(accept, "text/xml"):
+		strings.Contains(accept, "text/xml"),
+		strings.Contains(accept, "+xml"):
 		return "xml"
 
 	case strings.Contains(accept, "text/plain"):
 		return "txt"
 
 	case strings.Contains(accept, "application/json"),
-		strings.Contains(accept, "text/javascript"):
+		strings.Contains(accept, "text/javascript"),
+		strings.Contains(accept, "+json"):
 		return "json"
 	}
 
 	return "html"
 }
 
+// ResolvePathFormat strips the format suffix, such as ".json", from the url path.
+// Returns the stripped path and the format, or the origin path and "" if no known suffix.
+func ResolvePathFormat(path string) (string, string) {
+	i := strings.LastIndex(path, ".")
+	if i < 0 || strings.Contains(path[i:], "/") {
+		return path, ""
+	}
+
+	switch format := path[i+1:]; format {
+	case "html", "xml", "json", "txt":
+		return path[:i], format
+	}
+	return path, ""
+}
+
 // Write the header (for now, just the status code).
 // The status may be set directly by the application (c.Response.Status = 501).
 // if it isn't, then fall back to the provided status code.
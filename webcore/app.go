@@ -0,0 +1,217 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"github.com/roverli/light/conf"
+	"github.com/roverli/light/log"
+	"github.com/roverli/light/mux"
+	"github.com/roverli/light/session"
+	"github.com/roverli/light/web"
+	"github.com/roverli/utils/errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var _ http.Handler = &App{}
+
+// App is a light web application. It owns the router, filters, handlers
+// and the session manager, and serves http as a http.Handler.
+// So several apps can run in one process, or mount into other servers.
+type App struct {
+	Name           string
+	Config         conf.Config // Application config
+	SessionConfig  conf.Config // Session config, nil for disabling session
+	Router         mux.Router
+	SessionManager *session.Manager // Nil if session disabled
+
+	filters        []web.Filter
+	tmpFilters     []web.Filter
+	patternFilters []*patternFilter
+
+	mu        sync.Mutex        // Guards routes, names, providers and started, for handling at runtime
+	routes    map[string]*Route // Key is "method-hosturl"
+	names     map[string]*Route // Named routes
+	providers map[reflect.Type]*provider
+	started   bool
+	serving   atomic.Value // *serving, rebuilt on changes
+
+	errorHandlers map[int]func(c *web.Context, code int)
+	errorViews    map[int]string
+	errorStatus   map[error]int
+
+	startOnce sync.Once
+	startErr  errors.Error
+
+	streamsEnd chan struct{} // Closed by EndStreams
+	endOnce    sync.Once
+}
+
+// NewApp creates an app, with the filters registered by RegisterDefault.
+func NewApp(name string, config conf.Config) *App {
+	app := &App{
+		Name:          name,
+		Config:        config,
+		Router:        mux.New(name),
+		routes:        make(map[string]*Route),
+		names:         make(map[string]*Route),
+		providers:     make(map[reflect.Type]*provider),
+		errorHandlers: make(map[int]func(c *web.Context, code int)),
+		errorViews:    make(map[int]string),
+		errorStatus:   make(map[error]int),
+		streamsEnd:    make(chan struct{}),
+	}
+	app.tmpFilters = append(app.tmpFilters, defaultFilters...)
+	return app
+}
+
+// AppOf returns the app serving the request.
+func AppOf(c *web.Context) *App {
+	if app, ok := c.App.(*App); ok {
+		return app
+	}
+	return DefaultApp
+}
+
+// The routing table and the filter chains of the routes, swapped as a whole,
+// so the serving requests never see the table and the chains out of step.
+type serving struct {
+	table  mux.Table
+	chains map[string][]web.Filter // Route filters by route key
+}
+
+// Load the serving table and chains, nil before start.
+func (app *App) load() *serving {
+	s, _ := app.serving.Load().(*serving)
+	return s
+}
+
+// The snapshot loaded for the request, so routing and choosing the chain see the same one.
+// Contexts not served by the app load the current one.
+func servingOf(c *web.Context) *serving {
+	if s, ok := c.Serving.(*serving); ok {
+		return s
+	}
+	return AppOf(c).load()
+}
+
+// TableOf returns the routing table of the request, nil before start.
+func TableOf(c *web.Context) mux.Table {
+	if s := servingOf(c); s != nil {
+		return s.table
+	}
+	return nil
+}
+
+// RouteTable returns the routing table being served, nil before start.
+// The table is built from the urls added to Router, which is not started by the app.
+func (app *App) RouteTable() mux.Table {
+	if s := app.load(); s != nil {
+		return s.table
+	}
+	return nil
+}
+
+// EndStreams ends the event streams being served, and the later ones once their headers
+// are written. Call it on server shutdown, so draining the requests needn't wait for the streams,
+// while the other requests keep their contexts.
+func (app *App) EndStreams() {
+	app.endOnce.Do(func() {
+		close(app.streamsEnd)
+	})
+}
+
+// Start the app, called by ServeHTTP on the first request if not called.
+// Register session stores, filters and handlers before start.
+// If it fails, such as the provider cycles, the app answers 503 for all requests.
+func (app *App) Start() errors.Error {
+	app.startOnce.Do(func() {
+		if app.SessionConfig == nil {
+			log.Infof("light/web: No session config for app %s, session disabled.", app.Name)
+		} else {
+			var err errors.Error
+			app.SessionManager, err = session.New(app.SessionConfig)
+			if err != nil {
+				log.Errorf("light/web: Init session of app %s error. Error: %v", app.Name, err)
+			}
+		}
+
+		app.mu.Lock()
+		defer app.mu.Unlock()
+
+		app.Router.SetPolicy(app.routePolicy())
+		table, err := app.Router.Build()
+		if err != nil {
+			log.Errorf("light/web: Start router of app %s error. Error: %v", app.Name, err)
+			app.startErr = err
+		} else if err := app.checkConflicts(table); err != nil {
+			log.Errorf("light/web: Start router of app %s error. Error: %v", app.Name, err)
+			app.startErr = err
+		} else if err := app.checkProviders(); err != nil {
+			log.Errorf("light/web: Start providers of app %s error. Error: %v", app.Name, err)
+			app.startErr = err
+		}
+
+		// Inject the providers registered after the handlers.
+		for _, route := range app.routes {
+			route.Invoker.compile(app.providers)
+		}
+
+		// Ensure route filters are at the last of the chain.
+		app.filters = append(app.tmpFilters, &routeFilter{})
+		app.tmpFilters = nil
+		if app.startErr == nil {
+			app.serving.Store(&serving{table: table, chains: app.buildChains()})
+		}
+		app.started = true
+	})
+
+	return app.startErr
+}
+
+// The url normalization policy by config "routeTrailingSlash", "routeDuplicateSlash"
+// and "routeCase", one of "lenient", "redirect" and "strict".
+func (app *App) routePolicy() mux.Policy {
+	policy := mux.DefaultPolicy
+	modes := map[string]*mux.Mode{
+		"routeTrailingSlash":  &policy.TrailingSlash,
+		"routeDuplicateSlash": &policy.DuplicateSlash,
+		"routeCase":           &policy.Case,
+	}
+
+	for key, mode := range modes {
+		name := app.Config.String(key, "")
+		if name == "" {
+			continue
+		}
+		m, err := mux.ParseMode(name)
+		if err != nil {
+			log.Errorf("light/web: Bad config %s of app %s, use the default. Error: %v", key, app.Name, err)
+			continue
+		}
+		*mode = m
+	}
+	return policy
+}
+
+func (app *App) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if err := app.Start(); err != nil {
+		http.Error(resp, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	c := &web.Context{
+		Req:    req,
+		Ctx:    req.Context(),
+		Resp:   resp,
+		Params: &web.Params{},
+		App:    app,
+	}
+	if s := app.load(); s != nil {
+		c.Serving = s
+	}
+
+	newChain(app.filters).DoFilter(c)
+}
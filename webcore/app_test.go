@@ -0,0 +1,238 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"github.com/roverli/light/conf"
+	"github.com/roverli/light/webcore"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type user struct {
+	Id   int    `$:"id"`
+	Name string `$:"name" @:"required"`
+}
+
+func newTestServer() *httptest.Server {
+	app := webcore.NewApp("testApp", nil)
+
+	app.Handle("GET/user/(id)", func(u user) (int, interface{}) {
+		return http.StatusOK, u
+	}, webcore.Name("user"))
+	app.Handle("POST/users", func(u user, r *webcore.BindResult) (int, interface{}) {
+		if r.HasErrors() {
+			return http.StatusBadRequest, r.Messages()
+		}
+		return http.StatusCreated, u
+	})
+	app.Handle("DELETE/user/(id)", func() error {
+		return webcore.NewHttpError(http.StatusForbidden, "forbidden")
+	})
+
+	return httptest.NewServer(app)
+}
+
+func do(t *testing.T, method string, url string, contentType string, body string) (*http.Response, string) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp, strings.TrimSpace(string(data))
+}
+
+func TestApp(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	cases := []struct {
+		method, url, contentType, body string
+		status                         int
+		respBody                       string
+	}{
+		{"GET", "/user/1?name=li", "", "", 200, `{"Id":1,"Name":"li"}`},
+		{"POST", "/users", "application/json", `{"id": 2, "name": "rob"}`, 201, `{"Id":2,"Name":"rob"}`},
+		{"POST", "/users", "application/json", `{"id": 2}`, 400, `["name is required"]`},
+		{"POST", "/users", "application/json", `{"id": `, 400, "Bad Request"},
+		{"DELETE", "/user/1", "", "", 403, "Forbidden"},
+		{"PUT", "/user/1", "", "", 405, "Method Not Allowed"},
+		{"GET", "/none", "", "", 404, "Not Found"},
+	}
+
+	for _, c := range cases {
+		resp, body := do(t, c.method, server.URL+c.url, c.contentType, c.body)
+		if resp.StatusCode != c.status || body != c.respBody {
+			t.Errorf("%s %s: expected %d %s, got %d %s.", c.method, c.url, c.status, c.respBody, resp.StatusCode, body)
+		}
+	}
+
+	resp, _ := do(t, "PUT", server.URL+"/user/1", "", "")
+	if allow := resp.Header.Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("unexpected Allow header %s.", allow)
+	}
+}
+
+func TestAppRedirect(t *testing.T) {
+	app := webcore.NewApp("redirectApp", conf.Config{"routeTrailingSlash": "redirect", "routeCase": "redirect"})
+	app.Handle("GET|POST/Users/(id)", func() {})
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	cases := []struct {
+		method, url string
+		status      int
+		location    string
+	}{
+		{"GET", "/Users/1", 200, ""},
+		{"GET", "/users/1/?a=b", 301, "/Users/1?a=b"},
+		{"GET", "/users/1.json", 301, "/Users/1.json"},
+		{"POST", "/Users/1/", 308, "/Users/1"},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, server.URL+c.url, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != c.status || resp.Header.Get("Location") != c.location {
+			t.Errorf("%s %s: expected %d %s, got %d %s.", c.method, c.url, c.status, c.location,
+				resp.StatusCode, resp.Header.Get("Location"))
+		}
+	}
+}
+
+func TestAppHost(t *testing.T) {
+	app := webcore.NewApp("hostApp", nil)
+	app.Handle("GET/", func(p struct {
+		Tenant string `$:"tenant"`
+	}) (int, string) {
+		return http.StatusOK, p.Tenant
+	}, webcore.Host("(tenant).example.com"))
+	app.Handle("GET/", func() (int, string) {
+		return http.StatusOK, "any"
+	})
+	app.Handle("GET/hl/(page?)", func() (int, string) {
+		return http.StatusOK, "hl"
+	}, webcore.Host("api.example.com"))
+
+	for url, expected := range map[string]string{"http://api.example.com/hl": "hl", "http://api.example.com/hl/3": "hl"} {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		if body := resp.Body.String(); body != expected {
+			t.Errorf("%s: expected %s, got %s.", url, expected, body)
+		}
+	}
+
+	for host, expected := range map[string]string{"acme.example.com": "acme", "localhost": "any"} {
+		req := httptest.NewRequest("GET", "http://"+host+"/", nil)
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, req)
+
+		if body := resp.Body.String(); body != expected {
+			t.Errorf("host %s: expected %s, got %s.", host, expected, body)
+		}
+	}
+}
+
+func TestAppVersion(t *testing.T) {
+	app := webcore.NewApp("versionApp", nil)
+	for _, v := range []string{"", "1", "2"} {
+		version := v
+		app.Handle("GET/users", func() (int, string) {
+			return http.StatusOK, "v" + version
+		}, webcore.Version(version))
+	}
+	app.Handle("GET/v1/about", func() (int, string) {
+		return http.StatusOK, "about"
+	})
+	app.Handle("GET/list/(page?)", func() (int, string) {
+		return http.StatusOK, "list"
+	}, webcore.Version("2"))
+
+	cases := []struct {
+		url      string
+		header   string
+		value    string
+		expected string
+	}{
+		{"/users", "", "", "v"},
+		{"/users", "Accept", "application/vnd.app.v2+json", "v2"},
+		{"/users", "Accept", "application/json; version=1", "v1"},
+		{"/users", "X-Api-Version", "2", "v2"},
+		{"/users", "X-Api-Version", "3", "v"},
+		{"/v1/users", "", "", "v1"},
+		{"/v2/users.json", "", "", "v2"},
+		{"/v1/about", "", "", "about"},
+		{"/list", "X-Api-Version", "2", "list"},
+		{"/v2/list/3", "", "", "list"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.url, nil)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, req)
+
+		if body := resp.Body.String(); body != c.expected {
+			t.Errorf("%s %s=%s: expected %s, got %s.", c.url, c.header, c.value, c.expected, body)
+		}
+	}
+}
+
+func TestAppMethods(t *testing.T) {
+	app := webcore.NewApp("methodsApp", nil)
+	app.Handle("GET/item/(id)", func() (int, string) {
+		return http.StatusOK, "get"
+	})
+	app.Handle("PUT/item/(id)", func() (int, string) {
+		return http.StatusOK, "put"
+	})
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, req)
+		return resp
+	}
+
+	head := serve(httptest.NewRequest("HEAD", "/item/1", nil))
+	if head.Code != 200 || head.Body.Len() != 0 || head.Header().Get("Content-Type") == "" {
+		t.Errorf("unexpected HEAD response %d %q.", head.Code, head.Body.String())
+	}
+
+	options := serve(httptest.NewRequest("OPTIONS", "/item/1", nil))
+	if options.Code != 204 || options.Header().Get("Allow") != "GET, HEAD, OPTIONS, PUT" {
+		t.Errorf("unexpected OPTIONS response %d %s.", options.Code, options.Header().Get("Allow"))
+	}
+
+	form := httptest.NewRequest("POST", "/item/1", strings.NewReader("_method=put"))
+	form.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if resp := serve(form); resp.Body.String() != "put" {
+		t.Errorf("unexpected form override response %d %s.", resp.Code, resp.Body.String())
+	}
+
+	header := httptest.NewRequest("POST", "/item/1", nil)
+	header.Header.Set("X-HTTP-Method-Override", "PUT")
+	if resp := serve(header); resp.Body.String() != "put" {
+		t.Errorf("unexpected header override response %d %s.", resp.Code, resp.Body.String())
+	}
+}
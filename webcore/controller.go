@@ -0,0 +1,263 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"github.com/roverli/light/mux"
+	"github.com/roverli/light/web"
+	"reflect"
+	"strings"
+)
+
+// ControllerRoute maps the restful pattern relative to the controller prefix
+// to the action method, such as {"GET/(id:int)/avatar", "Avatar", nil}.
+type ControllerRoute struct {
+	Pattern string
+	Action  string
+	Options []Option
+}
+
+// The conventional actions of the controller, in registering order.
+// The member actions bind the first argument of basic kind to the "id" param,
+// such as Show(id int), which is declared as "(id:int)" if the argument is of integer kind.
+var conventions = []struct {
+	action  string
+	pattern string
+	member  bool
+}{
+	{"Index", "GET", false},
+	{"New", "GET/new", false},
+	{"Create", "POST", false},
+	{"Show", "GET/(id)", true},
+	{"Edit", "GET/(id)/edit", true},
+	{"Update", "PUT|PATCH/(id)", true},
+	{"Delete", "DELETE/(id)", true},
+}
+
+// Controllers having the Routes method register the routes besides the conventional ones,
+// the actions routed explicitly are not routed by convention.
+type routesController interface {
+	Routes() []ControllerRoute
+}
+
+// Controllers having the Before method run it before every action,
+// the action is skipped if it returns an error, which is rendered as the handler error.
+type beforeController interface {
+	Before(c *web.Context) error
+}
+
+// Controllers having the After method run it after every action invoked.
+type afterController interface {
+	After(c *web.Context, r *InvokeResult)
+}
+
+// Controller registers the controller of DefaultApp.
+func Controller(prefix string, controller interface{}, opts ...Option) {
+	DefaultApp.Controller(prefix, controller, opts...)
+}
+
+// Controller registers the action methods of the controller under the url prefix, such as
+// Controller("/users", &UserController{}). The actions are routed by convention:
+//
+//	Index   GET        /users
+//	New     GET        /users/new
+//	Create  POST       /users
+//	Show    GET        /users/(id)
+//	Edit    GET        /users/(id)/edit
+//	Update  PUT|PATCH  /users/(id)
+//	Delete  DELETE     /users/(id)
+//
+// and by the routes returned by the Routes() []ControllerRoute method if any.
+// The actions take arguments and return values as the handlers. The arguments of basic kind
+// bind to the route params by position, such as Avatar(id int) for "GET/(id:int)/avatar".
+// The params of declared types reply 404 if not convertible to the arguments, the member
+// id is declared as int for the argument of integer kind.
+//
+// Each request runs on a fresh copy of the controller, with the exported fields of
+// the provided types and *web.Context injected. The optional Before(c) error and
+// After(c, result) methods run around the action.
+//
+// The options apply to every action, the Name option is suffixed by the lower cased action,
+// such as "users.show". It panics if the controller is not a pointer to struct,
+// or the explicit route has less params than the arguments of basic kind.
+func (app *App) Controller(prefix string, controller interface{}, opts ...Option) {
+	registerController(prefix, controller, opts, app.Handle)
+}
+
+// Controller registers the controller under the url prefix relative to the group prefix.
+func (g *Group) Controller(prefix string, controller interface{}, opts ...Option) {
+	registerController(prefix, controller, opts, g.Handle)
+}
+
+func registerController(prefix string, controller interface{}, opts []Option,
+	handle func(url string, handler interface{}, opts ...Option)) {
+
+	v := reflect.ValueOf(controller)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic("light/web: Controller must be a pointer to struct, got " + v.Type().String() + ".")
+	}
+
+	base := &Route{}
+	for _, opt := range opts {
+		opt(base)
+	}
+
+	prefix = strings.TrimRight(prefix, "/")
+	add := func(pattern string, action string, member bool, explicit bool, actionOpts []Option) {
+		method, ok := v.Type().MethodByName(action)
+		if !ok {
+			panic("light/web: Controller " + v.Type().String() + " has no action " + action + ".")
+		}
+
+		i := strings.Index(pattern, "/")
+		if i < 0 {
+			i = len(pattern)
+		}
+		url := prefix + pattern[i:]
+		if url == "" {
+			url = "/"
+		}
+
+		all := make([]Option, 0, len(opts)+len(actionOpts)+1)
+		all = append(all, opts...)
+		if base.Name != "" {
+			all = append(all, Name(base.Name+"."+strings.ToLower(action)))
+		}
+		all = append(all, actionOpts...)
+
+		var params []string
+		switch {
+		case explicit:
+			ps, err := mux.Params(url)
+			if err != nil {
+				panic("light/web: Controller " + v.Type().String() + " has bad pattern " + pattern + ". " + err.Error())
+			}
+			for _, p := range ps {
+				params = append(params, p.Name)
+			}
+		case member:
+			params = []string{"id"}
+		}
+
+		invoker := newActionInvoker(v, method)
+		if !invoker.bindParams(params, explicit) {
+			panic("light/web: Controller " + v.Type().String() + " action " + action +
+				" has more arguments of basic kind than the params of " + pattern + ".")
+		}
+		// The id of integer kind is declared as int, so other ids are not found.
+		if member && invoker.bindsInteger("id") {
+			url = strings.Replace(url, "(id)", "(id:int)", 1)
+		}
+		handle(pattern[:i]+url, invoker, all...)
+	}
+
+	explicit := make(map[string]bool)
+	if rc, ok := controller.(routesController); ok {
+		for _, r := range rc.Routes() {
+			explicit[r.Action] = true
+			add(r.Pattern, r.Action, false, true, r.Options)
+		}
+	}
+
+	for _, conv := range conventions {
+		if _, ok := v.Type().MethodByName(conv.action); ok && !explicit[conv.action] {
+			add(conv.pattern, conv.action, conv.member, false, nil)
+		}
+	}
+}
+
+// Create the invoker of the action, the receiver is the fresh copy of the controller.
+func newActionInvoker(v reflect.Value, method reflect.Method) *Invoker {
+	invoker := toInvoker(method.Func.Interface())
+
+	_, before := v.Interface().(beforeController)
+	_, after := v.Interface().(afterController)
+	invoker.receiver = &receiver{proto: v.Elem(), before: before, after: after}
+	return invoker
+}
+
+// Bind the arguments of basic kind to the route params by position.
+// Returns false if strict and the params are less than the arguments.
+func (invoker *Invoker) bindParams(params []string, strict bool) bool {
+	n := 0
+	for _, arg := range invoker.Args[1:] {
+		if arg.Type == lastEventIDType || (arg.Type.Kind() != reflect.String && !checkConvertible(arg.Type)) {
+			continue
+		}
+		if n == len(params) {
+			return !strict
+		}
+		arg.Param = params[n]
+		n++
+	}
+	return true
+}
+
+// Is the route param bound to an argument of integer kind.
+func (invoker *Invoker) bindsInteger(param string) bool {
+	for _, arg := range invoker.Args {
+		if arg.Param != param {
+			continue
+		}
+		switch arg.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+	}
+	return false
+}
+
+// Receiver of the action, copied from the registered controller for each request.
+type receiver struct {
+	proto  reflect.Value // The registered controller struct
+	before bool
+	after  bool
+	fields []injectField // Resolved on compile
+}
+
+type injectField struct {
+	index []int
+	plan  argPlan
+}
+
+// Resolve the exported fields to inject.
+func (r *receiver) compile(providers map[reflect.Type]*provider) {
+	r.fields = nil
+	typ := r.proto.Type()
+	for i, num := 0, typ.NumField(); i < num; i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if p, ok := providers[field.Type]; ok {
+			r.fields = append(r.fields, injectField{field.Index, providerPlan(p)})
+		} else if field.Type == webContextType {
+			r.fields = append(r.fields, injectField{field.Index, contextPlan})
+		}
+	}
+}
+
+// Copy the controller, inject the fields and run Before.
+func (r *receiver) plan(c *web.Context, inv *invocation) (reflect.Value, bool) {
+	v := reflect.New(r.proto.Type())
+	elem := v.Elem()
+	elem.Set(r.proto)
+
+	for _, f := range r.fields {
+		fv, ok := f.plan(c, inv)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		elem.FieldByIndex(f.index).Set(fv)
+	}
+
+	if r.before {
+		if err := v.Interface().(beforeController).Before(c); err != nil {
+			inv.result.Err = err
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,112 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"fmt"
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type userController struct {
+	Prefix string
+	Count  *counter
+	C      *web.Context
+	trace  []string
+}
+
+func (ctrl *userController) Before(c *web.Context) error {
+	if c.Req.URL.Query().Get("deny") != "" {
+		return webcore.NewHttpError(http.StatusForbidden, "forbidden")
+	}
+	ctrl.trace = append(ctrl.trace, "before")
+	return nil
+}
+
+func (ctrl *userController) After(c *web.Context, r *webcore.InvokeResult) {
+	c.Resp.Header().Set("X-Trace", strings.Join(append(ctrl.trace, "after"), ","))
+}
+
+func (ctrl *userController) Index() (int, string) {
+	ctrl.trace = append(ctrl.trace, "index")
+	return http.StatusOK, ctrl.Prefix + "index"
+}
+
+func (ctrl *userController) Show(id int) (int, string) {
+	ctrl.Count.n++
+	return http.StatusOK, fmt.Sprintf("%sshow %d %s", ctrl.Prefix, id, ctrl.C.Req.Method)
+}
+
+func (ctrl *userController) Delete(id string) int {
+	return http.StatusNoContent
+}
+
+func (ctrl *userController) Avatar(id int) (int, string) {
+	return http.StatusOK, fmt.Sprintf("avatar %d", id)
+}
+
+func (ctrl *userController) Photo(c *web.Context, id int, name string) (int, string) {
+	return http.StatusOK, fmt.Sprintf("photo %d %s", id, name)
+}
+
+func (ctrl *userController) Routes() []webcore.ControllerRoute {
+	return []webcore.ControllerRoute{
+		{Pattern: "GET/(id:int)/avatar", Action: "Avatar"},
+		{Pattern: "GET/(id:int)/photos/(name)", Action: "Photo"},
+		{Pattern: "DELETE/(id)/remove", Action: "Delete"},
+	}
+}
+
+type badController struct{}
+
+func (ctrl *badController) Avatar(id int) {}
+
+func (ctrl *badController) Routes() []webcore.ControllerRoute {
+	return []webcore.ControllerRoute{{Pattern: "GET/avatar", Action: "Avatar"}}
+}
+
+func TestAppController(t *testing.T) {
+	app := webcore.NewApp("controllerApp", nil)
+	app.Provide(&counter{})
+	app.Controller("/users/", &userController{Prefix: "users "}, webcore.Name("users"))
+
+	cases := []struct {
+		method, url string
+		status      int
+		body, trace string
+	}{
+		{"GET", "/users", 200, "users index", "before,index,after"},
+		{"GET", "/users/7", 200, "users show 7 GET", "before,after"},
+		{"GET", "/users/x", 404, "Not Found", ""},
+		{"GET", "/users/7?deny=1", 403, "Forbidden", ""},
+		{"GET", "/users/7/avatar", 200, "avatar 7", "before,after"},
+		{"GET", "/users/x/avatar", 404, "Not Found", ""},
+		{"GET", "/users/7/photos/a.png", 200, "photo 7 a.png", "before,after"},
+		{"DELETE", "/users/7/remove", 204, "", "before,after"},
+		{"DELETE", "/users/7", 405, "Method Not Allowed", ""},
+	}
+	for _, c := range cases {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest(c.method, c.url, nil))
+		body := strings.TrimSpace(resp.Body.String())
+		if resp.Code != c.status || body != c.body || resp.Header().Get("X-Trace") != c.trace {
+			t.Errorf("%s %s: expected %d %s %s, got %d %s %s.", c.method, c.url, c.status, c.body, c.trace,
+				resp.Code, body, resp.Header().Get("X-Trace"))
+		}
+	}
+
+	if u, err := app.URL("users.show", map[string]int{"id": 1}); err != nil || u != "/users/1" {
+		t.Errorf("unexpected url %s, error %v.", u, err)
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("expected panic of the action argument without param.")
+		}
+	}()
+	app.Controller("/bad", &badController{})
+}
@@ -0,0 +1,127 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"errors"
+	"github.com/roverli/light/log"
+	"github.com/roverli/light/view"
+	"github.com/roverli/light/web"
+	"net/http"
+	"reflect"
+)
+
+// StatusError is an error carrying the http status code to reply.
+type StatusError interface {
+	error
+	Status() int
+}
+
+// HttpError is a simple StatusError.
+type HttpError struct {
+	Code int
+	Msg  string
+}
+
+func (e *HttpError) Error() string {
+	return e.Msg
+}
+
+func (e *HttpError) Status() int {
+	return e.Code
+}
+
+// NewHttpError returns an error replied with the status code.
+func NewHttpError(code int, msg string) error {
+	return &HttpError{Code: code, Msg: msg}
+}
+
+// ErrorStatus maps the error returned by handlers of DefaultApp to the status code.
+func ErrorStatus(err error, code int) {
+	DefaultApp.ErrorStatus(err, code)
+}
+
+// ErrorStatus maps the error returned by handlers to the status code.
+// The returned error matches if it is the mapped value or wraps it, such as by fmt.Errorf with %w.
+// Unmapped errors are replied with 500.
+func (app *App) ErrorStatus(err error, code int) {
+	app.errorStatus[err] = code
+}
+
+// Get the status code for the error returned by handlers, unwrapping it until
+// a StatusError or a mapped error is found.
+func (app *App) statusOf(err error) int {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(StatusError); ok {
+			return e.Status()
+		}
+		// Errors of uncomparable types can't be mapped.
+		if reflect.TypeOf(err).Comparable() {
+			if code, ok := app.errorStatus[err]; ok {
+				return code
+			}
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorHandler registers the error handler of DefaultApp for the status code.
+func ErrorHandler(code int, handler func(c *web.Context, code int)) {
+	DefaultApp.ErrorHandler(code, handler)
+}
+
+// ErrorHandler registers the handler to reply requests failed with the given status code.
+// The handler is responsible for writing the status code and the body.
+func (app *App) ErrorHandler(code int, handler func(c *web.Context, code int)) {
+	if _, dup := app.errorHandlers[code]; dup {
+		log.Warnf("light/web: duplicate error handler, code: %d.", code)
+	}
+	app.errorHandlers[code] = handler
+}
+
+// ErrorView registers the error view of DefaultApp for the status code.
+func ErrorView(code int, tpl string) {
+	DefaultApp.ErrorView(code, tpl)
+}
+
+// ErrorView registers the view template to render for the given status code.
+// The template is rendered with "Code", "Text" and "Url" in its context.
+func (app *App) ErrorView(code int, tpl string) {
+	if _, dup := app.errorViews[code]; dup {
+		log.Warnf("light/web: duplicate error view, code: %d.", code)
+	}
+	app.errorViews[code] = tpl
+}
+
+// Error replies the request with the status code.
+// A registered handler wins a registered view, plain status text is written if none
+// or the view is not found.
+func Error(c *web.Context, code int) {
+	app := AppOf(c)
+
+	if handler := app.errorHandlers[code]; handler != nil {
+		handler(c, code)
+		return
+	}
+
+	if tpl, ok := app.errorViews[code]; ok {
+		if !view.Exists(tpl) {
+			log.Errorf("light/web: Error view %s not found, code: %d.", tpl, code)
+		} else {
+			c.Resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+			c.Resp.WriteHeader(code)
+
+			err := view.Render(tpl, view.Context{
+				"Code": code,
+				"Text": http.StatusText(code),
+				"Url":  c.Req.URL.Path,
+			}, c.Resp)
+			if err != nil {
+				log.Errorf("light/web: Render error view error, code: %d. %v", code, err)
+			}
+			return
+		}
+	}
+
+	http.Error(c.Resp, http.StatusText(code), code)
+}
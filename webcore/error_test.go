@@ -0,0 +1,78 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Controller rendering a view with the status set after the action.
+type viewController struct{}
+
+func (ctrl *viewController) Index() (string, interface{}) {
+	return "missing", nil
+}
+
+func (ctrl *viewController) After(c *web.Context, r *webcore.InvokeResult) {
+	r.Status = http.StatusCreated
+}
+
+func TestAppErrors(t *testing.T) {
+	errDenied := errors.New("denied")
+	app := webcore.NewApp("errorsApp", nil)
+	app.ErrorStatus(errDenied, http.StatusForbidden)
+	app.ErrorView(http.StatusForbidden, "missing")
+	app.ErrorHandler(http.StatusNotFound, func(c *web.Context, code int) {
+		c.Resp.WriteHeader(code)
+		fmt.Fprintf(c.Resp, "no page %s", c.Req.URL.Path)
+	})
+
+	app.Handle("GET/denied", func() error {
+		return errDenied
+	})
+	app.Handle("GET/failed", func() error {
+		return errors.New("failed")
+	})
+	app.Handle("GET/wrapped", func() error {
+		return fmt.Errorf("delete item: %w", errDenied)
+	})
+	app.Handle("GET/wrappedGone", func() error {
+		return fmt.Errorf("find item: %w", webcore.NewHttpError(http.StatusGone, "gone"))
+	})
+	app.Handle("GET/gone", func() error {
+		return webcore.NewHttpError(http.StatusGone, "gone")
+	})
+	app.Handle("GET/view", func() (string, interface{}) {
+		return "missing", nil
+	})
+	app.Controller("/views/", &viewController{})
+
+	cases := []struct {
+		url    string
+		status int
+		body   string
+	}{
+		{"/denied", 403, "Forbidden"}, // The error view not found
+		{"/wrapped", 403, "Forbidden"},
+		{"/wrappedGone", 410, "Gone"},
+		{"/failed", 500, "Internal Server Error"},
+		{"/gone", 410, "Gone"},
+		{"/none", 404, "no page /none"},
+		{"/view", 500, "Internal Server Error"},
+		{"/views", 500, "Internal Server Error"},
+	}
+	for _, c := range cases {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", c.url, nil))
+		if body := strings.TrimSpace(resp.Body.String()); resp.Code != c.status || body != c.body {
+			t.Errorf("%s: expected %d %s, got %d %s.", c.url, c.status, c.body, resp.Code, body)
+		}
+	}
+}
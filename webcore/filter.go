@@ -3,17 +3,112 @@
 package webcore
 
 import (
-	"github.com/roverli/light/view"
+	"context"
+	"fmt"
+	"github.com/roverli/light/log"
 	"github.com/roverli/light/web"
+	"net/http"
+	"strings"
 )
 
-var (
-	filters    []web.Filter
-	tmpFilters []web.Filter
-)
-
+// Register the filter for all requests of DefaultApp.
 func Register(f web.Filter) {
-	tmpFilters = append(tmpFilters, f)
+	DefaultApp.Register(f)
+}
+
+// Register the filter for all requests. It panics if the app is started,
+// register the filters for the routes by RegisterFor at runtime.
+func (app *App) Register(f web.Filter) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	if app.started {
+		panic(fmt.Sprintf("light/web: Register filter %T after the app started.", f))
+	}
+	app.tmpFilters = append(app.tmpFilters, f)
+}
+
+// Filter attached to the routes matching the pattern.
+type patternFilter struct {
+	methods []string // Empty for all methods
+	pieces  []string // Url prefix pieces
+	filter  web.Filter
+}
+
+// RegisterFor registers the filter for the routes of DefaultApp matching the pattern.
+func RegisterFor(pattern string, f web.Filter) {
+	DefaultApp.RegisterFor(pattern, f)
+}
+
+// RegisterFor registers the filter for the routes matching the pattern.
+// Pattern is a restful url prefix with optional methods, such as "/admin/(*)" or "POST/".
+// A route matches if its url starts with the pattern pieces, the ending "(*)" is optional.
+// Pattern filters run after the routing, before the filters of the route itself.
+// It can be called while serving, the route chains are rebuilt.
+func (app *App) RegisterFor(pattern string, f web.Filter) {
+	i := strings.Index(pattern, "/")
+	if i < 0 {
+		log.Errorf("light/web: bad filter pattern, pattern: %s.", pattern)
+		return
+	}
+
+	pf := &patternFilter{pieces: splitUrl(pattern[i:]), filter: f}
+	if n := len(pf.pieces); n > 0 && (pf.pieces[n-1] == "(*)" || pf.pieces[n-1] == "*") {
+		pf.pieces = pf.pieces[:n-1]
+	}
+	if i > 0 {
+		pf.methods = splitMethods(pattern[:i])
+	}
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.patternFilters = append(app.patternFilters, pf)
+	if app.started {
+		if err := app.reload(); err != nil {
+			log.Errorf("light/web: RegisterFor %s at runtime fail. Error: %v", pattern, err)
+		}
+	}
+}
+
+func (pf *patternFilter) match(method string, pieces []string) bool {
+	if len(pf.methods) > 0 && !containsString(pf.methods, method) {
+		return false
+	}
+	if len(pf.pieces) > len(pieces) {
+		return false
+	}
+	for i, piece := range pf.pieces {
+		if pieces[i] != piece {
+			return false
+		}
+	}
+	return true
+}
+
+// Build the filters of each route: pattern filters, route filters and the InvokeFilter.
+// The chains are swapped along with the routing table, see serving.
+func (app *App) buildChains() map[string][]web.Filter {
+	chains := make(map[string][]web.Filter, len(app.routes))
+
+	for key, route := range app.routes {
+		method := key[:strings.Index(key, "-")]
+		chains[key] = append(app.routeFilters(method, route), &InvokeFilter{route: route})
+	}
+	return chains
+}
+
+// The pattern filters and the filters of the route.
+func (app *App) routeFilters(method string, route *Route) []web.Filter {
+	pieces := splitUrl(route.Url)
+
+	var filters []web.Filter
+	for _, pf := range app.patternFilters {
+		if pf.match(method, pieces) {
+			filters = append(filters, pf.filter)
+		}
+	}
+	return append(filters, route.Filters...)
 }
 
 type filterChain struct {
@@ -33,13 +128,50 @@ func newChain(filters []web.Filter) web.FilterChain {
 	return &filterChain{filters: filters}
 }
 
+// Run the filters of the matched route, at the last of the global chain.
+type routeFilter struct {
+}
+
+func (f *routeFilter) DoFilter(c *web.Context, chain web.FilterChain) {
+	method := c.RouteMethod
+	if method == "" {
+		method = c.Req.Method
+	}
+
+	var routeChain []web.Filter
+	if s := servingOf(c); s != nil {
+		routeChain = s.chains[routeKey(method, c.RouteResult.Host, c.RouteResult.Version, c.RouteResult.Url)]
+	}
+	if routeChain == nil {
+		Error(c, http.StatusNotFound)
+		return
+	}
+	newChain(routeChain).DoFilter(c)
+}
+
+// Invoke the route handler, at the last of the route chain.
 type InvokeFilter struct {
+	route *Route
 }
 
 func (f *InvokeFilter) DoFilter(c *web.Context, chain web.FilterChain) {
-	invoker := invokers[c.Req.Method+"-"+c.RouteResult.Url]
-	r := invoker.Invoke(c)
+	// Stream handlers are not invoked for HEAD, nothing would read what they produce.
+	if c.Req.Method == "HEAD" && f.route.Invoker.Out.Stream {
+		streamHeader(c, http.StatusOK)
+		return
+	}
+
+	if timeout := f.route.Timeout; timeout > 0 {
+		ctx, cancel := context.WithTimeout(c.Ctx, timeout)
+		defer cancel()
+		c.Ctx = ctx
+		c.Req = c.Req.WithContext(ctx)
+	}
+
+	r := f.route.Invoker.Invoke(c)
+	if r.Err != nil && c.Ctx.Err() == context.DeadlineExceeded {
+		r.Err = NewHttpError(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+	}
 
-	// TODO support mutlti returns
-	view.Render(r.Values[0].String(), r.Model, c.Resp)
+	render(c, f.route, r)
 }
@@ -0,0 +1,68 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Filter appending its name to the X-Filters header.
+type traceFilter string
+
+func (f traceFilter) DoFilter(c *web.Context, chain web.FilterChain) {
+	c.Resp.Header().Add("X-Filters", string(f))
+	chain.DoFilter(c)
+}
+
+func TestAppFilters(t *testing.T) {
+	app := webcore.NewApp("filtersApp", nil)
+	app.Register(traceFilter("global"))
+	app.RegisterFor("/admin/(*)", traceFilter("admin"))
+	app.RegisterFor("POST/admin", traceFilter("post"))
+
+	hello := func() (int, string) {
+		return http.StatusOK, "hello"
+	}
+	admin := app.Group("/admin", traceFilter("group"))
+	admin.Handle("GET|POST/users", hello, webcore.Filters(traceFilter("route")))
+	admin.Group("/sub", traceFilter("sub")).Handle("GET/", hello)
+	app.Handle("GET/home", hello)
+
+	filters := func(method string, url string) string {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest(method, url, nil))
+		return strings.Join(resp.Header()["X-Filters"], ",")
+	}
+
+	cases := []struct {
+		method, url, expected string
+	}{
+		{"GET", "/admin/users", "global,admin,group,route"},
+		{"POST", "/admin/users", "global,admin,post,group,route"},
+		{"GET", "/admin/sub", "global,admin,group,sub"},
+		{"GET", "/home", "global"},
+	}
+	for _, c := range cases {
+		if actual := filters(c.method, c.url); actual != c.expected {
+			t.Errorf("%s %s: expected filters %s, got %s.", c.method, c.url, c.expected, actual)
+		}
+	}
+
+	// Pattern filters apply at runtime, global ones can't.
+	app.RegisterFor("/home", traceFilter("home"))
+	if actual := filters("GET", "/home"); actual != "global,home" {
+		t.Errorf("expected filters global,home at runtime, got %s.", actual)
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("expected panic of registering after start.")
+		}
+	}()
+	app.Register(traceFilter("late"))
+}
@@ -0,0 +1,49 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"github.com/roverli/light/log"
+	"github.com/roverli/light/web"
+	"strings"
+)
+
+// Group shares the url prefix and filters among routes.
+type Group struct {
+	app     *App
+	prefix  string
+	filters []web.Filter
+}
+
+// NewGroup creates a route group of DefaultApp.
+func NewGroup(prefix string, filters ...web.Filter) *Group {
+	return DefaultApp.Group(prefix, filters...)
+}
+
+// Group creates a route group with the url prefix, such as "/admin".
+func (app *App) Group(prefix string, filters ...web.Filter) *Group {
+	return &Group{app: app, prefix: strings.TrimRight(prefix, "/"), filters: filters}
+}
+
+// Group creates a sub group, inherits the prefix and filters of the parent.
+func (g *Group) Group(prefix string, filters ...web.Filter) *Group {
+	all := make([]web.Filter, 0, len(g.filters)+len(filters))
+	all = append(all, g.filters...)
+	all = append(all, filters...)
+	return g.app.Group(g.prefix+"/"+strings.Trim(prefix, "/"), all...)
+}
+
+// Handle registers the handler for the restful pattern relative to the group prefix.
+// The group filters run before the filters of the route.
+func (g *Group) Handle(url string, handler interface{}, opts ...Option) {
+	i := strings.Index(url, "/")
+	if i < 0 {
+		log.Errorf("light/web: bad restful httpUrl, url: %s.", url)
+		return
+	}
+
+	groupOpts := make([]Option, 0, len(opts)+1)
+	groupOpts = append(groupOpts, Filters(g.filters...))
+	groupOpts = append(groupOpts, opts...)
+	g.app.Handle(url[:i]+g.prefix+url[i:], handler, groupOpts...)
+}
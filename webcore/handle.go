@@ -4,35 +4,242 @@ package webcore
 
 import (
 	"github.com/roverli/light/log"
-	"github.com/roverli/utils/slice"
+	"github.com/roverli/light/mux"
+	"github.com/roverli/light/validate"
+	"github.com/roverli/light/web"
+	"github.com/roverli/utils/errors"
 	"reflect"
 	"strings"
+	"time"
 )
 
-var (
-	invokers = make(map[string]*Invoker)
-)
+// Route is a registered handler with its options.
+type Route struct {
+	Methods []string
+	Url     string
+	Name    string        // Name for building the url, see URL
+	Host    string        // Host pattern, empty for any host
+	Version string        // API version, empty for the default
+	Timeout time.Duration // Timeout of the request context, zero for none
+	Format  string        // Forced response format, empty for negotiation
+	Filters []web.Filter  // Filters for this route only
+	Invoker *Invoker
+
+	pattern *mux.Pattern // Parsed url for building, on the first URL call
+}
+
+// Option configures the route when registering handler.
+type Option func(r *Route)
+
+// Format forces the response format of the route, one of "html", "xml", "json" or "txt".
+func Format(format string) Option {
+	return func(r *Route) {
+		r.Format = format
+	}
+}
+
+// Name names the route, so the url can be built by URL.
+func Name(name string) Option {
+	return func(r *Route) {
+		r.Name = name
+	}
+}
+
+// Host restricts the route to the host pattern, such as "api.example.com".
+// The pieces can be params as the url, eg. "(tenant).example.com",
+// the host params are parsed into the route params.
+func Host(pattern string) Option {
+	return func(r *Route) {
+		r.Host = pattern
+	}
+}
+
+// Version declares the API version of the route, such as "2".
+// The same pattern can be handled by different versions, the request selects one
+// by the Accept media type "application/vnd.<app>.v2+json", the version header
+// or the url prefix "/v2", then falls back to the route without version or the latest one.
+func Version(version string) Option {
+	return func(r *Route) {
+		r.Version = version
+	}
+}
+
+// Timeout cancels the request context of the route after the duration, such as the
+// queries of the db Context methods. The handler failed after timeout replies 503.
+func Timeout(d time.Duration) Option {
+	return func(r *Route) {
+		r.Timeout = d
+	}
+}
+
+// Filters attaches the filters to the route.
+func Filters(filters ...web.Filter) Option {
+	return func(r *Route) {
+		r.Filters = append(r.Filters, filters...)
+	}
+}
 
-func Handle(url string, handler interface{}) {
+// Handle registers the handler of DefaultApp for the restful pattern.
+func Handle(url string, handler interface{}, opts ...Option) {
+	DefaultApp.Handle(url, handler, opts...)
+}
+
+// Handle registers the handler for the restful pattern, such as "GET|POST/user/(id)".
+// It can be called while serving, the route takes effect atomically,
+// and is rolled back if the router fails to rebuild.
+func (app *App) Handle(url string, handler interface{}, opts ...Option) {
 	i := strings.Index(url, "/")
 	if i < 0 {
 		log.Errorf("light/web: bad restful httpUrl, url: %s.", url)
 		return
 	}
 
-	methods := slice.MapString(strings.Split(url[:i], "|"), func(s string) string { return strings.TrimSpace(s) })
-	slice.Foreach(methods, func(method string) {
-		key := strings.TrimSpace(method) + "-" + url[i:]
-		if _, dup := invokers[key]; dup {
-			log.Warnf("light/web: duplicate httpUrl, url: %s.", url)
-		} else {
-			invokers[key] = toInvoker(handler)
+	methods := splitMethods(url[:i])
+	route := &Route{Methods: methods, Url: url[i:], Invoker: toInvoker(handler)}
+	for _, opt := range opts {
+		opt(route)
+	}
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	// Check the duplicates before registering anything.
+	var fresh, keys []string
+	for _, method := range methods {
+		key := routeKey(method, route.Host, route.Version, route.Url)
+		if _, dup := app.routes[key]; dup {
+			log.Warnf("light/web: duplicate httpUrl, method: %s, url: %s.", method, url)
+			continue
+		}
+		fresh = append(fresh, method)
+		keys = append(keys, key)
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	named := route.Name != ""
+	if _, dup := app.names[route.Name]; named && dup {
+		log.Warnf("light/web: duplicate route name %s, url: %s.", route.Name, url)
+		named = false
+	}
+
+	// Check the new table before serving it, only the urls of this call are rolled back on failure.
+	route.Invoker.setParamTypes(route.Url)
+	route.Invoker.compile(app.providers)
+	app.Router.AddVersion(fresh, route.Host, route.Version, route.Url)
+	var table mux.Table
+	if app.started {
+		var err errors.Error
+		if table, err = app.buildTable(); err != nil {
+			log.Errorf("light/web: Handle %s at runtime fail, roll back. Error: %v", url, err)
+			app.Router.Remove(fresh, route.Host, route.Version, route.Url)
+			return
 		}
-	})
-	Router.Add(methods, url[i:])
+	}
+
+	for _, key := range keys {
+		app.routes[key] = route
+	}
+	if named {
+		app.names[route.Name] = route
+	}
+	if table != nil {
+		app.serving.Store(&serving{table: table, chains: app.buildChains()})
+	}
+}
+
+// Unhandle unregisters the handler of DefaultApp.
+func Unhandle(url string, opts ...Option) {
+	DefaultApp.Unhandle(url, opts...)
+}
+
+// Unhandle unregisters the handler of the restful pattern, the same as registered.
+// Only the Host and Version options matter. It can be called while serving.
+func (app *App) Unhandle(url string, opts ...Option) {
+	i := strings.Index(url, "/")
+	if i < 0 {
+		log.Errorf("light/web: bad restful httpUrl, url: %s.", url)
+		return
+	}
+
+	route := &Route{Url: url[i:]}
+	for _, opt := range opts {
+		opt(route)
+	}
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.unhandle(splitMethods(url[:i]), route.Host, route.Version, route.Url)
+	if app.started {
+		if err := app.reload(); err != nil {
+			log.Errorf("light/web: Unhandle %s at runtime fail. Error: %v", url, err)
+		}
+	}
+}
+
+// Remove the route of the methods, all methods if empty.
+func (app *App) unhandle(methods []string, host string, version string, url string) {
+	removed := make(map[*Route]bool)
+	for key, route := range app.routes {
+		if route.Host != host || route.Version != version || route.Url != url {
+			continue
+		}
+		if len(methods) == 0 || containsString(methods, key[:strings.Index(key, "-")]) {
+			delete(app.routes, key)
+			removed[route] = true
+		}
+	}
+
+	// Keep the name if the route still serves other methods.
+	for _, route := range app.routes {
+		delete(removed, route)
+	}
+	for name, route := range app.names {
+		if removed[route] {
+			delete(app.names, name)
+		}
+	}
+	app.Router.Remove(methods, host, version, url)
+}
+
+// Build the routing table and check the conflicts.
+func (app *App) buildTable() (mux.Table, errors.Error) {
+	table, err := app.Router.Build()
+	if err != nil {
+		return nil, err
+	}
+	if err := app.checkConflicts(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// Rebuild the routing table and the route chains after start, swapped as a whole.
+// Nothing is swapped if the table fails.
+func (app *App) reload() errors.Error {
+	table, err := app.buildTable()
+	if err != nil {
+		return err
+	}
+	app.serving.Store(&serving{table: table, chains: app.buildChains()})
+	return nil
+}
+
+func routeKey(method string, host string, version string, url string) string {
+	if version != "" {
+		url += "@" + version
+	}
+	return method + "-" + host + url
 }
 
 func toInvoker(handler interface{}) *Invoker {
+	// Prepared, such as the controller actions.
+	if invoker, ok := handler.(*Invoker); ok {
+		return invoker
+	}
+
 	v := reflect.ValueOf(handler)
 	t := v.Type()
 
@@ -76,31 +283,68 @@ func toInvoker(handler interface{}) *Invoker {
 				}
 
 				// Tag wins field name.
-				switch name := field.Tag.Get("$"); name {
+				name := field.Tag.Get("$")
+				switch name {
 				case "":
-					arg.ExportFields[field.Name] = field.Index
+					name = field.Name
 				case "-": //ignore
-				default:
-					arg.ExportFields[name] = field.Index
+					continue
 				}
+				arg.ExportFields[name] = field.Index
 
-				// TODO: support validate
 				// For Validate
-				// if tag := field.Tag.Get("@"); tag != "" {
-				// 	var _ tag
-				// }
+				if tag := field.Tag.Get("@"); tag != "" {
+					rules, err := validate.Compile(tag)
+					if err != nil {
+						panic("light/web: Bad validate tag of field " + field.Name + ". " + err.Error())
+					}
+					if arg.Rules == nil {
+						arg.Rules = make(map[string]*validate.Rules)
+					}
+					arg.Rules[name] = rules
+				}
 			}
 		}
 
 		invoker.Args[i] = arg
 	}
 
-	// TODO: support multi?
-	// for i, numOut := 0, t.NumOut(); i < numOut; i++ {
-	// 	switch t.Out(i).Kind() {
-	// 	case reflect.String:
-	// 	case reflect.
-	// 	}
-	// }
+	invoker.Out = toInvokeOut(t)
 	return invoker
 }
+
+func toInvokeOut(t reflect.Type) InvokeOut {
+	out := InvokeOut{View: -1, Model: -1, Status: -1, Body: -1, Err: -1}
+
+	numOut := t.NumOut()
+	if numOut > 0 && t.Out(numOut-1) == errorType {
+		numOut--
+		out.Err = numOut
+	}
+
+	switch numOut {
+	case 0:
+	case 1:
+		switch t.Out(0).Kind() {
+		case reflect.String:
+			out.View = 0
+		case reflect.Int:
+			out.Status = 0
+		default:
+			out.Body = 0
+		}
+	case 2:
+		switch t.Out(0).Kind() {
+		case reflect.String:
+			out.View, out.Model = 0, 1
+		case reflect.Int:
+			out.Status, out.Body = 0, 1
+		default:
+			panic("light/web: Handler returns must be (string, model) or (int, body), got " + t.String() + ".")
+		}
+	default:
+		panic("light/web: Handler returns too many values, got " + t.String() + ".")
+	}
+	out.Stream = out.Body >= 0 && isStreamType(t.Out(out.Body))
+	return out
+}
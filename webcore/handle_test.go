@@ -0,0 +1,162 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/roverli/light/conf"
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAppHotHandle(t *testing.T) {
+	app := webcore.NewApp("hotApp", nil)
+	app.Handle("GET/", func() (int, string) {
+		return http.StatusOK, "home"
+	})
+	app.Start()
+
+	status := func(url string) int {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		return resp.Code
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			status("/feature")
+		}
+	}()
+
+	if code := status("/feature"); code != 404 {
+		t.Errorf("expected 404 before handle, got %d.", code)
+	}
+	app.Handle("GET/feature", func() (int, string) {
+		return http.StatusOK, "feature"
+	}, webcore.Name("feature"))
+	if code := status("/feature"); code != 200 {
+		t.Errorf("expected 200 after handle, got %d.", code)
+	}
+
+	app.Unhandle("GET/feature")
+	if code := status("/feature"); code != 404 {
+		t.Errorf("expected 404 after unhandle, got %d.", code)
+	}
+	if _, err := app.URL("feature", nil); err == nil {
+		t.Error("expected the name removed.")
+	}
+
+	// Bad url is rolled back.
+	app.Handle("GET/bad/a()b", func() {})
+	if code := status("/"); code != 200 {
+		t.Errorf("expected 200 after rollback, got %d.", code)
+	}
+	<-done
+}
+
+func TestAppHotHandleRollback(t *testing.T) {
+	app := webcore.NewApp("rollbackApp", conf.Config{"routeConflict": "fail"})
+	app.Handle("GET|POST/a/(x)", func() (int, string) {
+		return http.StatusOK, "x"
+	})
+	app.Start()
+
+	body := func(method string, url string) string {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest(method, url, nil))
+		return resp.Body.String()
+	}
+
+	// Duplicate and conflicting routes keep the live one.
+	app.Handle("GET/a/(x)", func() (int, string) {
+		return http.StatusOK, "dup"
+	})
+	app.Handle("GET|PUT/a/(y)", func() (int, string) {
+		return http.StatusOK, "y"
+	})
+	for _, method := range []string{"GET", "POST"} {
+		if b := body(method, "/a/1"); b != "x" {
+			t.Errorf("expected x of %s after rollback, got %s.", method, b)
+		}
+	}
+	if b := body("PUT", "/a/1"); b == "y" {
+		t.Error("expected the conflicting route rolled back.")
+	}
+
+	// The new methods of the same url are added.
+	app.Handle("GET|DELETE/a/(x)", func() (int, string) {
+		return http.StatusOK, "delete"
+	})
+	if b := body("GET", "/a/1"); b != "x" {
+		t.Errorf("expected x of GET, got %s.", b)
+	}
+	if b := body("DELETE", "/a/1"); b != "delete" {
+		t.Errorf("expected delete of DELETE, got %s.", b)
+	}
+}
+
+func TestAppTimeout(t *testing.T) {
+	app := webcore.NewApp("timeoutApp", nil)
+	app.Handle("GET/slow", func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}, webcore.Timeout(10*time.Millisecond))
+	app.Handle("GET/fast", func(ctx context.Context, c *web.Context) (int, string) {
+		_, deadline := ctx.Deadline()
+		return http.StatusOK, fmt.Sprint(deadline, ctx == c.Req.Context())
+	})
+
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/slow", nil))
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d.", resp.Code)
+	}
+
+	resp = httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/fast", nil))
+	if body := resp.Body.String(); body != "false true" {
+		t.Errorf("unexpected body %s.", body)
+	}
+}
+
+// Filter unhandling the route once it's routed, before the route chain is chosen.
+type unhandleFilter struct {
+	app *webcore.App
+}
+
+func (f *unhandleFilter) DoFilter(c *web.Context, chain web.FilterChain) {
+	f.app.Unhandle("GET/once")
+	chain.DoFilter(c)
+}
+
+func TestAppHandleSnapshot(t *testing.T) {
+	app := webcore.NewApp("snapshotApp", nil)
+	app.Register(&unhandleFilter{app})
+	app.Handle("GET/once", func() (int, string) {
+		return http.StatusOK, "once"
+	})
+
+	// The request is served by the table and chains it was routed with.
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/once", nil))
+	if resp.Code != http.StatusOK || resp.Body.String() != "once" {
+		t.Errorf("expected 200 once, got %d %s.", resp.Code, resp.Body.String())
+	}
+
+	resp = httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/once", nil))
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after unhandle, got %d.", resp.Code)
+	}
+}
@@ -4,42 +4,39 @@ package webcore
 
 import (
 	"github.com/roverli/light/conf"
-	"github.com/roverli/light/log"
-	"github.com/roverli/light/mux"
-	"github.com/roverli/light/session"
+	"github.com/roverli/light/web"
 	"github.com/roverli/utils/errors"
-	"sync"
+	"net/http"
 )
 
 var (
-	Router         mux.Router = mux.New("LightRouter")
-	SessionManager *session.Manager
+	// DefaultApp is the app used by the package functions.
+	DefaultApp = newDefaultApp()
+
+	// Filters for all apps, see RegisterDefault.
+	defaultFilters []web.Filter
 )
 
-var initOnce sync.Once
+func newDefaultApp() *App {
+	app := NewApp(conf.App.String("app", "LightRouter"), conf.App)
+	app.SessionConfig = conf.Session
+	return app
+}
+
+// RegisterDefault registers the filter for DefaultApp and apps created later.
+// The filter may serve several apps, so get the app by AppOf.
+func RegisterDefault(f web.Filter) {
+	defaultFilters = append(defaultFilters, f)
+	DefaultApp.Register(f)
+}
 
 // Call by light package.
 // So clients have chance to register their own SessionStore, Filter...
 func Start() errors.Error {
-	var err errors.Error
-	initOnce.Do(func() {
-		config, err := conf.Load("session.conf")
-		if err != nil {
-			log.Error("light/web: Load session config error. Error: %v", err)
-		} else {
-			SessionManager, err = session.New(config)
-			if err != nil {
-				log.Error("light/web: Init session error. Error: %v", err)
-			}
-		}
-
-		Router.Start()
-
-		// Ensure InvokerFilter is at the last of the chain.
-		filters = tmpFilters
-		tmpFilters = nil
-		filters = append(filters, &InvokeFilter{})
-	})
-
-	return err
+	return DefaultApp.Start()
+}
+
+// Invoke serves the request by DefaultApp.
+func Invoke(resp http.ResponseWriter, req *http.Request) {
+	DefaultApp.ServeHTTP(resp, req)
 }
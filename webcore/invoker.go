@@ -3,79 +3,446 @@
 package webcore
 
 import (
+	"context"
 	"github.com/roverli/light/bind"
 	_ "github.com/roverli/light/log"
+	"github.com/roverli/light/mux"
 	"github.com/roverli/light/session"
 	"github.com/roverli/light/web"
+	"github.com/roverli/light/validate"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
 )
 
 var (
+	contextType      = reflect.TypeOf((*context.Context)(nil)).Elem()
 	httpRequestType  = reflect.TypeOf(http.Request{})
 	httpResponseType = reflect.TypeOf((*http.ResponseWriter)(nil)).Elem()
 	httpSessionType  = reflect.TypeOf((*session.Session)(nil)).Elem()
 	bindResultType   = reflect.TypeOf(BindResult{})
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
 )
 
+// BindResult collects the validation errors of the handler arguments.
 type BindResult struct {
+	Errors map[string][]string // Messages by param name
 }
 
+// Return true if any argument is invalid.
+func (r *BindResult) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// Get the first message of the param, or "" if valid.
+func (r *BindResult) Error(name string) string {
+	if msgs := r.Errors[name]; len(msgs) > 0 {
+		return msgs[0]
+	}
+	return ""
+}
+
+// Get all messages in "name msg" form, eg. "email must be an email address".
+func (r *BindResult) Messages() []string {
+	var msgs []string
+	for name, errs := range r.Errors {
+		for _, err := range errs {
+			msgs = append(msgs, name+" "+err)
+		}
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+func (r *BindResult) add(name string, msgs []string) {
+	if r.Errors == nil {
+		r.Errors = make(map[string][]string)
+	}
+	r.Errors[name] = append(r.Errors[name], msgs...)
+}
+
+// Invoker calls the handler, with the arguments bound by the plan compiled once on Handle.
 type Invoker struct {
 	Args []*InvokeArg
 	Func reflect.Value
+	Out  InvokeOut
+
+	bindResultArg int               // Index of the *BindResult argument, -1 if absent
+	receiver      *receiver         // Receiver of the controller action, nil for functions
+	paramTypes    map[string]string // Declared types of the route params by name, such as "int"
+}
+
+// Indexes of the handler return values, -1 if absent.
+// Supported returns are: string (view), (string, model), (int, body),
+// a single body, nothing, and any of them followed by an error.
+// The body of a channel or an iterator is streamed as server-sent events.
+type InvokeOut struct {
+	View   int
+	Model  int
+	Status int
+	Body   int
+	Err    int
+	Stream bool // If the body is a channel or an iterator streamed as events
 }
 
 type InvokeResult struct {
 	Values []reflect.Value
-	Model  map[string]interface{} //.For view rendering
+	Model  interface{} // For view rendering
+	View   string      // View name, empty if handler rendered nothing
+	Status int         // Http status code, zero if not returned
+	Body   interface{} // Response body when no view returned
+	Err    error       // Error returned by handler
 }
 
 func (invoker *Invoker) Invoke(c *web.Context) *InvokeResult {
 
-	invokeResult := &InvokeResult{}
-
+	inv := &invocation{}
 	in := make([]reflect.Value, len(invoker.Args))
+	for i, arg := range invoker.Args {
+		v, ok := arg.plan(c, inv)
+		if !ok {
+			return &inv.result
+		}
+		in[i] = v
+	}
+
+	// Set after all arguments validated.
+	if i := invoker.bindResultArg; i >= 0 {
+		v := reflect.ValueOf(&inv.bind)
+		if !invoker.Args[i].IsPtr {
+			v = v.Elem()
+		}
+		in[i] = v
+	}
+
+	invokeResult := &inv.result
+	values := invoker.Func.Call(in)
+	invokeResult.Values = values
+
+	out := invoker.Out
+	if out.View >= 0 {
+		invokeResult.View = values[out.View].String()
+	}
+	if out.Model >= 0 {
+		// Without view name, the model is serialized as the body.
+		if invokeResult.View == "" {
+			invokeResult.Body = values[out.Model].Interface()
+		} else {
+			invokeResult.Model = values[out.Model].Interface()
+		}
+	}
+	if out.Status >= 0 {
+		invokeResult.Status = int(values[out.Status].Int())
+	}
+	if out.Body >= 0 {
+		invokeResult.Body = values[out.Body].Interface()
+	}
+	if out.Err >= 0 && !values[out.Err].IsNil() {
+		invokeResult.Err = values[out.Err].Interface().(error)
+	}
+
+	invoker.after(c, in, invokeResult)
+	return invokeResult
+}
+
+// Run the After method of the controller action.
+func (invoker *Invoker) after(c *web.Context, in []reflect.Value, r *InvokeResult) {
+	if invoker.receiver != nil && invoker.receiver.after {
+		in[0].Interface().(afterController).After(c, r)
+	}
+}
+
+// Set the declared types of the params of the route pattern, such as "int" of "(id:int)".
+func (invoker *Invoker) setParamTypes(pattern string) {
+	params, _ := mux.Params(pattern) // Bad patterns fail on routing
+	invoker.paramTypes = make(map[string]string, len(params))
+	for _, p := range params {
+		if p.Type != "" {
+			invoker.paramTypes[p.Name] = p.Type
+		}
+	}
+}
+
+// Is the type checked by convertible.
+func checkConvertible(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+// Can the string convert to the number or bool type strictly, without overflow.
+// Other types are not checked.
+func convertible(str string, typ reflect.Type) bool {
+	var err error
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(str, 10, typ.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(str, 10, typ.Bits())
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(str, typ.Bits())
+	case reflect.Bool:
+		_, err = strconv.ParseBool(str)
+	}
+	return err == nil
+}
+
+type InvokeArg struct {
+	Index        int
+	Type         reflect.Type
+	IsPtr        bool
+	ExportFields map[string][]int
+	Rules        map[string]*validate.Rules // Validate rules by field name
+	Param        string                     // Route param bound to the argument of basic kind, such as "id"
+
+	plan argPlan
+}
+
+// State of one invocation shared by the argument plans.
+type invocation struct {
+	result   InvokeResult
+	bind     BindResult
+	provided map[*provider]reflect.Value // Values of request scope
+}
+
+// Plan to make the argument value from the request.
+// Return false to stop invoking, with the error set to the result.
+type argPlan func(c *web.Context, inv *invocation) (reflect.Value, bool)
+
+// Plan to bind and validate a field of the struct argument.
+type fieldPlan func(c *web.Context, v reflect.Value, inv *invocation) bool
+
+// Compile the plans of the arguments, so invoking needn't look up
+// the types, binders and providers again.
+func (invoker *Invoker) compile(providers map[reflect.Type]*provider) {
+	invoker.bindResultArg = -1
 	for _, arg := range invoker.Args {
-		v := reflect.New(arg.Type).Elem()
+		if arg.Index == 0 && invoker.receiver != nil {
+			invoker.receiver.compile(providers)
+			arg.plan = invoker.receiver.plan
+			continue
+		}
+		if arg.Param != "" {
+			arg.plan = arg.paramPlan(invoker.paramTypes[arg.Param] != "")
+			continue
+		}
+
+		typ := arg.Type
+		if arg.IsPtr {
+			typ = reflect.PtrTo(typ)
+		}
+		if p, ok := providers[typ]; ok {
+			arg.plan = providerPlan(p)
+			continue
+		}
+
+		switch typ {
+		case webContextType:
+			arg.plan = contextPlan
+			continue
+		case contextType:
+			arg.plan = func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+				return reflect.ValueOf(&c.Ctx).Elem(), true
+			}
+			continue
+		case lastEventIDType:
+			arg.plan = func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+				return reflect.ValueOf(LastEventID(c.Req.Header.Get("Last-Event-ID"))), true
+			}
+			continue
+		}
+
 		switch arg.Type {
-		case reflect.TypeOf(http.Request{}):
-			v.Set(reflect.ValueOf(c.Req).Elem())
+		case httpRequestType:
+			if arg.IsPtr {
+				arg.plan = func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+					return reflect.ValueOf(c.Req), true
+				}
+			} else {
+				arg.plan = func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+					return reflect.ValueOf(c.Req).Elem(), true
+				}
+			}
 		case httpResponseType:
-			v.Set(reflect.ValueOf(c.Resp))
+			arg.plan = arg.interfacePlan(func(c *web.Context) interface{} {
+				return c.Resp
+			})
 		case httpSessionType:
-			v.Set(reflect.ValueOf(c.Session))
+			// Nil if session disabled.
+			arg.plan = arg.interfacePlan(func(c *web.Context) interface{} {
+				if c.Session == nil {
+					return nil
+				}
+				return c.Session
+			})
 		case bindResultType:
-			v.Set(reflect.New(arg.Type))
+			invoker.bindResultArg = arg.Index
+			arg.plan = func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+				return reflect.Value{}, true
+			}
 		default:
 			switch arg.Type.Kind() {
 			case reflect.Map:
-				mapArg := reflect.MakeMap(arg.Type)
-				invokeResult.Model = mapArg.Interface().(map[string]interface{})
-				v.Set(mapArg)
-
+				arg.plan = arg.mapPlan()
 			case reflect.Struct:
-				for name, index := range arg.ExportFields {
-					r := bind.Bind(c.Params, name, arg.Type.FieldByIndex(index).Type)
-					v.FieldByIndex(index).Set(r)
-				}
+				arg.plan = arg.structPlan(invoker.paramTypes)
+			default:
+				arg.plan = arg.zeroPlan()
 			}
 		}
+	}
+}
 
-		if arg.IsPtr {
-			v = v.Addr()
+// Plan of the argument injected by the provider.
+func providerPlan(p *provider) argPlan {
+	return func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+		v, err := p.get(c, inv)
+		if err != nil {
+			inv.result.Err = err
+			return reflect.Value{}, false
 		}
-		in[arg.Index] = v
+		return v, true
 	}
+}
 
-	invokeResult.Values = invoker.Func.Call(in)
+// Plan of the *web.Context argument.
+func contextPlan(c *web.Context, inv *invocation) (reflect.Value, bool) {
+	return reflect.ValueOf(c), true
+}
 
-	return invokeResult
+// Plan of the argument got from the context, such as the response writer.
+func (arg *InvokeArg) interfacePlan(get func(c *web.Context) interface{}) argPlan {
+	typ, isPtr := arg.Type, arg.IsPtr
+	return func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+		x := get(c)
+		if !isPtr {
+			if x == nil {
+				return reflect.Zero(typ), true
+			}
+			return reflect.ValueOf(x), true
+		}
+
+		v := reflect.New(typ)
+		if x != nil {
+			v.Elem().Set(reflect.ValueOf(x))
+		}
+		return v, true
+	}
 }
 
-type InvokeArg struct {
-	Index        int
-	Type         reflect.Type
-	IsPtr        bool
-	ExportFields map[string][]int
+// Plan of the map argument, which is the model for view rendering.
+func (arg *InvokeArg) mapPlan() argPlan {
+	typ, isPtr := arg.Type, arg.IsPtr
+	return func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+		m := reflect.MakeMap(typ)
+		inv.result.Model = m.Interface()
+		if isPtr {
+			v := reflect.New(typ)
+			v.Elem().Set(m)
+			return v, true
+		}
+		return m, true
+	}
+}
+
+// Plan of the struct argument, binding and validating the exported fields in order.
+func (arg *InvokeArg) structPlan(paramTypes map[string]string) argPlan {
+	names := make(map[string]string, len(arg.ExportFields))
+	for name, index := range arg.ExportFields {
+		names[arg.Type.FieldByIndex(index).Name] = name
+	}
+
+	var fields []fieldPlan
+	for i, num := 0, arg.Type.NumField(); i < num; i++ {
+		if name, ok := names[arg.Type.Field(i).Name]; ok {
+			fields = append(fields, arg.fieldPlan(name, paramTypes[name] != ""))
+		}
+	}
+
+	typ, isPtr := arg.Type, arg.IsPtr
+	return func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+		v := reflect.New(typ)
+		elem := v.Elem()
+		for _, field := range fields {
+			if !field(c, elem, inv) {
+				return reflect.Value{}, false
+			}
+		}
+		if isPtr {
+			return v, true
+		}
+		return elem, true
+	}
+}
+
+func (arg *InvokeArg) fieldPlan(name string, typed bool) fieldPlan {
+	index := arg.ExportFields[name]
+	typ := arg.Type.FieldByIndex(index).Type
+	rules := arg.Rules[name]
+	binder, found := bind.BinderFor(typ)
+
+	// Params of the declared route type must convert, or the resource is not found.
+	check := typed && checkConvertible(typ)
+
+	return func(c *web.Context, v reflect.Value, inv *invocation) bool {
+		if check {
+			if vals := c.Params.Route[name]; len(vals) > 0 && !convertible(vals[0], typ) {
+				inv.result.Err = NewHttpError(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+				return false
+			}
+		}
+
+		field := v.FieldByIndex(index)
+		if found {
+			field.Set(binder.Bind(c.Params, name, typ))
+		}
+		if rules != nil {
+			if msgs := rules.Validate(field); msgs != nil {
+				inv.bind.add(name, msgs)
+			}
+		}
+		return true
+	}
+}
+
+// Plan of the argument bound to the route param, the resource is not found
+// if the param of the declared route type doesn't convert.
+func (arg *InvokeArg) paramPlan(typed bool) argPlan {
+	name, typ, isPtr := arg.Param, arg.Type, arg.IsPtr
+	binder, found := bind.BinderFor(typ)
+	check := typed && checkConvertible(typ)
+
+	return func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+		if vals := c.Params.Route[name]; check && len(vals) > 0 && !convertible(vals[0], typ) {
+			inv.result.Err = NewHttpError(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return reflect.Value{}, false
+		}
+
+		v := reflect.Zero(typ)
+		if found {
+			v = binder.Bind(c.Params, name, typ)
+		}
+		if isPtr {
+			p := reflect.New(typ)
+			p.Elem().Set(v)
+			return p, true
+		}
+		return v, true
+	}
+}
+
+// Plan of the argument not bound, the zero value.
+func (arg *InvokeArg) zeroPlan() argPlan {
+	typ, isPtr := arg.Type, arg.IsPtr
+	return func(c *web.Context, inv *invocation) (reflect.Value, bool) {
+		if isPtr {
+			return reflect.New(typ), true
+		}
+		return reflect.Zero(typ), true
+	}
 }
@@ -0,0 +1,68 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"fmt"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAppTypedParams(t *testing.T) {
+	app := webcore.NewApp("typedApp", nil)
+	app.Handle("GET/item/(id:int)", func(p struct {
+		Id int8 `$:"id"`
+	}) (int, interface{}) {
+		return http.StatusOK, p.Id
+	})
+	// Untyped params bind as they can.
+	app.Handle("GET/any/(id)", func(p struct {
+		Id int8 `$:"id"`
+	}) (int, interface{}) {
+		return http.StatusOK, p.Id
+	})
+
+	for url, expected := range map[string]int{"/item/12": 200, "/item/abc": 404, "/item/300": 404, "/any/abc": 200} {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", url, nil))
+		if resp.Code != expected {
+			t.Errorf("%s: expected %d, got %d.", url, expected, resp.Code)
+		}
+	}
+}
+
+func TestAppInvokeArgs(t *testing.T) {
+	app := webcore.NewApp("invokeApp", nil)
+	app.Handle("GET/user/(id:slug)", func(req http.Request, u *user, r webcore.BindResult, n int) (int, string) {
+		return http.StatusOK, fmt.Sprintf("%s %d %s %d", req.Method, u.Id, r.Error("name"), n)
+	})
+
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/user/7", nil))
+	if body := resp.Body.String(); body != "GET 7 is required 0" {
+		t.Errorf("unexpected body %s.", body)
+	}
+
+	resp = httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/user/abc", nil))
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d.", resp.Code)
+	}
+}
+
+func BenchmarkAppInvoke(b *testing.B) {
+	app := webcore.NewApp("benchApp", nil)
+	app.Handle("GET/user/(id)", func(u user) (int, interface{}) {
+		return http.StatusOK, u
+	})
+	req := httptest.NewRequest("GET", "/user/1?name=li", nil)
+	req.Header.Set("Accept", "application/json")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"fmt"
+	"github.com/roverli/light/web"
+	"github.com/roverli/utils/errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var webContextType = reflect.TypeOf((*web.Context)(nil))
+
+// Scope of the provided values, the longer ones outlive the shorter.
+type Scope int
+
+const (
+	RequestScope Scope = iota // Created once per request
+	SessionScope              // Created once per session, kept in the session attributes
+	AppScope                  // Created once per app, a singleton
+)
+
+func (s Scope) String() string {
+	switch s {
+	case RequestScope:
+		return "request"
+	case SessionScope:
+		return "session"
+	case AppScope:
+		return "app"
+	}
+	return fmt.Sprintf("Scope(%d)", int(s))
+}
+
+// Provider of a type, injected into the handler arguments and the other providers.
+type provider struct {
+	typ   reflect.Type
+	scope Scope
+	fn    reflect.Value  // Provider function, invalid for the singleton value
+	deps  []reflect.Type // Argument types of the function
+	err   bool           // If the function returns an error as the last
+
+	depProviders []*provider // Resolved on start, nil for *web.Context
+
+	mu    sync.Mutex // Guards value of app scope
+	value reflect.Value
+}
+
+// Provide registers the provider of DefaultApp.
+func Provide(provider interface{}, scope ...Scope) {
+	DefaultApp.Provide(provider, scope...)
+}
+
+// Provide registers the provider by the type it returns, so the handlers and other
+// providers can declare arguments of the type directly.
+//
+// The provider is a function returning the value, or the value and an error, such as
+// func(c *web.Context, db *db.DB) (*UserService, error). Its arguments are injected
+// the same way, *web.Context is the request context. The scope defaults to RequestScope.
+// Other values are singletons of AppScope, such as Provide(db).
+//
+// The dependencies are checked on start, it fails for the missing ones, cycles,
+// or depending on the shorter scope. It panics if the type is provided,
+// the provider is bad or the app is started.
+func (app *App) Provide(provider interface{}, scope ...Scope) {
+	p := newProvider(provider, scope...)
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	if app.started {
+		panic("light/web: Provide " + p.typ.String() + " after the app started.")
+	}
+	if _, dup := app.providers[p.typ]; dup {
+		panic("light/web: Duplicate provider of " + p.typ.String() + ".")
+	}
+	app.providers[p.typ] = p
+}
+
+func newProvider(v interface{}, scope ...Scope) *provider {
+	fn := reflect.ValueOf(v)
+	if !fn.IsValid() {
+		panic("light/web: Provider must not be nil.")
+	}
+
+	if fn.Kind() != reflect.Func {
+		if len(scope) > 0 && scope[0] != AppScope {
+			panic("light/web: Provided value of " + fn.Type().String() + " must be app scope.")
+		}
+		return &provider{typ: fn.Type(), scope: AppScope, value: fn}
+	}
+
+	t := fn.Type()
+	p := &provider{fn: fn}
+	switch {
+	case t.NumOut() == 1 && t.Out(0) != errorType:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+		p.err = true
+	default:
+		panic("light/web: Provider must return a value, or a value and an error, got " + t.String() + ".")
+	}
+	p.typ = t.Out(0)
+	if len(scope) > 0 {
+		p.scope = scope[0]
+	}
+
+	for i := 0; i < t.NumIn(); i++ {
+		p.deps = append(p.deps, t.In(i))
+	}
+	return p
+}
+
+// Resolve the dependencies of the providers, find the missing ones,
+// the cycles and the ones depending on shorter scopes.
+// The providers are resolved only if all the checks pass.
+func (app *App) checkProviders() errors.Error {
+	resolved := make(map[*provider][]*provider, len(app.providers))
+	for _, p := range app.providers {
+		deps := make([]*provider, len(p.deps))
+		for i, dep := range p.deps {
+			if dep == webContextType {
+				if p.scope == AppScope {
+					return errors.Newf("light/web: Provider of %s in app scope can't depend on *web.Context.", p.typ)
+				}
+				continue
+			}
+
+			dp, ok := app.providers[dep]
+			if !ok {
+				return errors.Newf("light/web: No provider of %s, required by %s.", dep, p.typ)
+			}
+			if dp.scope < p.scope {
+				return errors.Newf("light/web: Provider of %s in %s scope can't depend on %s in %s scope.",
+					p.typ, p.scope, dep, dp.scope)
+			}
+			deps[i] = dp
+		}
+		resolved[p] = deps
+	}
+
+	// Depth first search, the providers on the stack are visiting.
+	visited := make(map[*provider]bool)
+	var stack []*provider
+	var visit func(p *provider) errors.Error
+	visit = func(p *provider) errors.Error {
+		for i, q := range stack {
+			if q == p {
+				var names []string
+				for _, q := range append(stack[i:], p) {
+					names = append(names, q.typ.String())
+				}
+				return errors.Newf("light/web: Provider cycle %s.", strings.Join(names, " -> "))
+			}
+		}
+		if visited[p] {
+			return nil
+		}
+
+		stack = append(stack, p)
+		for _, dp := range resolved[p] {
+			if dp != nil {
+				if err := visit(dp); err != nil {
+					return err
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		visited[p] = true
+		return nil
+	}
+
+	for _, p := range app.providers {
+		if err := visit(p); err != nil {
+			return err
+		}
+	}
+
+	for p, deps := range resolved {
+		p.depProviders = deps
+	}
+	return nil
+}
+
+// Get the value in the scope, creating it if absent.
+// Request scope values are cached in the invocation.
+func (p *provider) get(c *web.Context, inv *invocation) (reflect.Value, error) {
+	switch p.scope {
+	case AppScope:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.value.IsValid() {
+			return p.value, nil
+		}
+		v, err := p.call(c, inv)
+		if err == nil {
+			p.value = v
+		}
+		return v, err
+
+	case SessionScope:
+		if c.Session == nil {
+			return reflect.Value{}, errors.Newf("light/web: Provide %s in session scope without session.", p.typ)
+		}
+		key := "light/web: provided " + p.typ.String()
+		if attr := c.Session.GetAttribute(key); attr != nil {
+			return reflect.ValueOf(attr), nil
+		}
+		v, err := p.call(c, inv)
+		if err == nil {
+			c.Session.SetAttribute(key, v.Interface())
+		}
+		return v, err
+	}
+
+	if v, ok := inv.provided[p]; ok {
+		return v, nil
+	}
+	v, err := p.call(c, inv)
+	if err == nil {
+		if inv.provided == nil {
+			inv.provided = make(map[*provider]reflect.Value)
+		}
+		inv.provided[p] = v
+	}
+	return v, err
+}
+
+// Call the provider function with the dependencies.
+func (p *provider) call(c *web.Context, inv *invocation) (reflect.Value, error) {
+	if !p.fn.IsValid() {
+		return p.value, nil
+	}
+
+	in := make([]reflect.Value, len(p.deps))
+	for i, dp := range p.depProviders {
+		if dp == nil {
+			in[i] = reflect.ValueOf(c)
+			continue
+		}
+		v, err := dp.get(c, inv)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		in[i] = v
+	}
+
+	out := p.fn.Call(in)
+	if p.err && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
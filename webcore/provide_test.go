@@ -0,0 +1,72 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"fmt"
+	"github.com/roverli/light/web"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type counter struct {
+	n int
+}
+
+type greeter struct {
+	path  string
+	count *counter
+}
+
+type cycleA struct{}
+type cycleB struct{}
+
+func TestAppProvide(t *testing.T) {
+	app := webcore.NewApp("provideApp", nil)
+	app.Handle("GET/hello/(name)", func(g *greeter, c *web.Context, p struct {
+		Name string `$:"name"`
+	}) (int, string) {
+		g.count.n++
+		return http.StatusOK, fmt.Sprintf("%s %s %d %v", p.Name, g.path, g.count.n, c != nil)
+	})
+	app.Provide(&counter{})
+	app.Provide(func(c *web.Context, count *counter) *greeter {
+		return &greeter{path: c.Req.URL.Path, count: count}
+	})
+	if err := app.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []string{"li /hello/li 1 true", "rob /hello/rob 2 true"} {
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", "/hello/"+[]string{"li", "rob"}[i], nil))
+		if body := resp.Body.String(); body != expected {
+			t.Errorf("expected %s, got %s.", expected, body)
+		}
+	}
+
+	cycle := webcore.NewApp("cycleApp", nil)
+	cycle.Provide(func(*cycleB) *cycleA { return nil })
+	cycle.Provide(func(*cycleA) (*cycleB, error) { return nil, nil })
+	cycle.Handle("GET/cycle", func(a *cycleA) int {
+		return http.StatusOK
+	})
+	resp := httptest.NewRecorder()
+	cycle.ServeHTTP(resp, httptest.NewRequest("GET", "/cycle", nil))
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d of the cycle app, got %d.", http.StatusServiceUnavailable, resp.Code)
+	}
+	if err := cycle.Start(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got %v.", err)
+	}
+
+	scope := webcore.NewApp("scopeApp", nil)
+	scope.Provide(func() *cycleA { return nil })
+	scope.Provide(func(*cycleA) *cycleB { return nil }, webcore.AppScope)
+	if err := scope.Start(); err == nil || !strings.Contains(err.Error(), "request scope") {
+		t.Errorf("expected scope error, got %v.", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/roverli/light/log"
+	"github.com/roverli/light/view"
+	"github.com/roverli/light/web"
+	"io"
+	"net/http"
+)
+
+// Serializer for the handler returned body.
+type renderer struct {
+	contentType string
+	encode      func(w io.Writer, v interface{}) error
+}
+
+var renderers = map[string]renderer{
+	"json": {"application/json; charset=utf-8", func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v)
+	}},
+	"xml": {"application/xml; charset=utf-8", func(w io.Writer, v interface{}) error {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		return xml.NewEncoder(w).Encode(v)
+	}},
+	"txt": {"text/plain; charset=utf-8", func(w io.Writer, v interface{}) error {
+		_, err := fmt.Fprint(w, v)
+		return err
+	}},
+}
+
+// Resolve the response format.
+// The route forced format wins the url suffix, and the suffix wins the Accept header.
+func resolveFormat(c *web.Context, route *Route) string {
+	switch {
+	case route.Format != "":
+		return route.Format
+	case c.Format != "":
+		return c.Format
+	default:
+		return web.ResolveFormat(c.Req)
+	}
+}
+
+// Write the handler result to the response.
+func render(c *web.Context, route *Route, r *InvokeResult) {
+	switch {
+	case r.Err != nil:
+		code := AppOf(c).statusOf(r.Err)
+		if code >= http.StatusInternalServerError {
+			log.Errorf("light/web: Handle error, url: %s. %v", c.Req.URL.Path, r.Err)
+		}
+		Error(c, code)
+
+	case r.View != "":
+		// Checked before the header is written, so the error can still be replied.
+		if !view.Exists(r.View) {
+			log.Errorf("light/web: View %s not found, url: %s.", r.View, c.Req.URL.Path)
+			Error(c, http.StatusInternalServerError)
+			return
+		}
+
+		c.Resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.Status != 0 {
+			c.Resp.WriteHeader(r.Status)
+		}
+		if err := view.Render(r.View, r.Model, c.Resp); err != nil {
+			log.Errorf("light/web: Render view %s error. %v", r.View, err)
+		}
+
+	case route.Invoker.Out.Stream && r.Body != nil:
+		stream(c, r.Status, r.Body)
+
+	case r.Status != 0 || r.Body != nil:
+		writeBody(c, r.Status, r.Body, resolveFormat(c, route))
+	}
+}
+
+// Write the body in format. Strings and bytes are written as they are.
+func writeBody(c *web.Context, status int, body interface{}, format string) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	switch b := body.(type) {
+	case nil:
+		c.Resp.WriteHeader(status)
+
+	case []byte:
+		c.Resp.WriteHeader(status)
+		c.Resp.Write(b)
+
+	case string:
+		if c.Resp.Header().Get("Content-Type") == "" {
+			c.Resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		c.Resp.WriteHeader(status)
+		io.WriteString(c.Resp, b)
+
+	default:
+		r, ok := renderers[format]
+		if !ok {
+			// No view for html, fall back to json.
+			r = renderers["json"]
+		}
+
+		// Encode before writing, so errors can still be replied.
+		var buf bytes.Buffer
+		if err := r.encode(&buf, b); err != nil {
+			log.Errorf("light/web: Render %s body error, url: %s. %v", format, c.Req.URL.Path, err)
+			Error(c, http.StatusInternalServerError)
+			return
+		}
+
+		c.Resp.Header().Set("Content-Type", r.contentType)
+		c.Resp.WriteHeader(status)
+		buf.WriteTo(c.Resp)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"fmt"
+	"github.com/roverli/light/log"
+	"github.com/roverli/light/mux"
+	"github.com/roverli/utils/errors"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method  string
+	Host    string
+	Version string
+	Pattern string
+	Name    string
+	Handler string   // Function name of the handler
+	Filters []string // Type names of the pattern filters and the route filters in order
+}
+
+// Routes lists the routes of DefaultApp.
+func Routes() []RouteInfo {
+	return DefaultApp.Routes()
+}
+
+// Routes lists the routes ordered by pattern, host, version and method.
+func (app *App) Routes() []RouteInfo {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	infos := make([]RouteInfo, 0, len(app.routes))
+	for key, route := range app.routes {
+		info := RouteInfo{
+			Method:  key[:strings.Index(key, "-")],
+			Host:    route.Host,
+			Version: route.Version,
+			Pattern: route.Url,
+			Name:    route.Name,
+			Handler: runtime.FuncForPC(route.Invoker.Func.Pointer()).Name(),
+		}
+		for _, f := range app.routeFilters(info.Method, route) {
+			info.Filters = append(info.Filters, fmt.Sprintf("%T", f))
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Sort(sortedRouteInfos(infos))
+	return infos
+}
+
+type sortedRouteInfos []RouteInfo
+
+func (a sortedRouteInfos) Len() int {
+	return len(a)
+}
+
+func (a sortedRouteInfos) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+}
+
+func (a sortedRouteInfos) Less(i, j int) bool {
+	switch {
+	case a[i].Pattern != a[j].Pattern:
+		return a[i].Pattern < a[j].Pattern
+	case a[i].Host != a[j].Host:
+		return a[i].Host < a[j].Host
+	case a[i].Version != a[j].Version:
+		return a[i].Version < a[j].Version
+	}
+	return a[i].Method < a[j].Method
+}
+
+// Explain how DefaultApp routes the request.
+func Explain(method string, rawurl string) []mux.Trace {
+	return DefaultApp.Explain(method, rawurl)
+}
+
+// Explain how the request is routed after the app started, by tracing each route of the method.
+// The url is a path, or an absolute url for the routes with host patterns.
+func (app *App) Explain(method string, rawurl string) []mux.Trace {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil
+	}
+	table := app.RouteTable()
+	if table == nil {
+		return nil
+	}
+	return table.Explain(method, u.Host, u.Path)
+}
+
+// Warn the route conflicts, or fail if config "routeConflict" is "fail".
+func (app *App) checkConflicts(table mux.Table) errors.Error {
+	conflicts := table.Conflicts()
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if app.Config.String("routeConflict", "warn") == "fail" {
+		msgs := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			msgs[i] = c.String()
+		}
+		return errors.Newf("light/web: Route conflicts: %s.", strings.Join(msgs, "; "))
+	}
+
+	for _, c := range conflicts {
+		log.Warnf("light/web: Route conflict of app %s, %s.", app.Name, c)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"github.com/roverli/light/conf"
+	"github.com/roverli/light/filter"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAppRoutes(t *testing.T) {
+	app := webcore.NewApp("routesApp", nil)
+	app.RegisterFor("/admin/(*)", &filter.ParamsFilter{})
+	app.Handle("GET|POST/admin/users", newTestServer, webcore.Name("users"))
+
+	routes := app.Routes()
+	if len(routes) != 2 || routes[0].Method != "GET" || routes[1].Method != "POST" {
+		t.Fatalf("unexpected routes %v.", routes)
+	}
+
+	r := routes[0]
+	if r.Pattern != "/admin/users" || r.Name != "users" || !strings.HasSuffix(r.Handler, ".newTestServer") ||
+		len(r.Filters) != 1 || r.Filters[0] != "*filter.ParamsFilter" {
+		t.Errorf("unexpected route %v.", r)
+	}
+}
+
+func TestAppRouteConflict(t *testing.T) {
+	hello := func() (int, string) {
+		return http.StatusOK, "hello"
+	}
+	for _, mode := range []string{"warn", "fail"} {
+		app := webcore.NewApp("conflictApp", conf.Config{"routeConflict": mode})
+		app.Handle("GET/a/(x)", hello)
+		app.Handle("GET/a/(y)", hello)
+
+		resp := httptest.NewRecorder()
+		app.ServeHTTP(resp, httptest.NewRequest("GET", "/a/1", nil))
+		expected := http.StatusOK
+		if mode == "fail" {
+			expected = http.StatusServiceUnavailable
+		}
+		if resp.Code != expected {
+			t.Errorf("expected status %d of mode %s, got %d.", expected, mode, resp.Code)
+		}
+	}
+}
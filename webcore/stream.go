@@ -0,0 +1,200 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/roverli/light/log"
+	"github.com/roverli/light/web"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var lastEventIDType = reflect.TypeOf(LastEventID(""))
+
+// Event is a server-sent event. The streams of other values send them as the data.
+type Event struct {
+	Id    string      // Sent back by the reconnecting client as Last-Event-ID
+	Name  string      // Event type, empty for "message"
+	Data  interface{} // Strings and bytes are sent as they are, others in json
+	Retry int         // Reconnection time in milliseconds, zero for the client default
+}
+
+// LastEventID is the Last-Event-ID header of the reconnecting client, empty for the first
+// connection. Declare the handler argument of this type to resume the stream.
+type LastEventID string
+
+// Is the handler returned type streamed, a receivable channel or an iterator
+// such as func(yield func(Event) bool).
+func isStreamType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan:
+		return t.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return false
+		}
+		yield := t.In(0)
+		return yield.Kind() == reflect.Func && yield.NumIn() == 1 &&
+			yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool
+	}
+	return false
+}
+
+// Write the channel or iterator as text/event-stream, flushing each event.
+// A comment is sent as the heartbeat if idle for config "streamHeartbeat" seconds,
+// defaults to 15, zero or less for none. It ends when the channel is closed, the iterator returns,
+// the request context is done, such as the client disconnected, or the app ends the streams.
+// The channel sender should stop on the request context done.
+// A nil channel or iterator replies 204, which tells the client not to reconnect.
+// HEAD requests get the header only, the stream handlers are not invoked for them.
+func stream(c *web.Context, status int, body interface{}) {
+	source := reflect.ValueOf(body)
+	if source.IsNil() {
+		c.Resp.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	streamHeader(c, status)
+	flush(c.Resp)
+
+	events, stop := streamSource(source)
+	defer stop()
+
+	app := AppOf(c)
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.Ctx.Done())},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(app.streamsEnd)},
+		{Dir: reflect.SelectRecv, Chan: events},
+		{Dir: reflect.SelectRecv}, // Ignored without heartbeat
+	}
+	if heartbeat := time.Duration(app.Config.Float("streamHeartbeat", 15) * float64(time.Second)); heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		cases[3].Chan = reflect.ValueOf(ticker.C)
+	}
+	for {
+		var err error
+		chosen, recv, ok := reflect.Select(cases)
+		switch chosen {
+		case 0, 1:
+			return
+		case 2:
+			if !ok {
+				return
+			}
+			err = writeEvent(c.Resp, recv.Interface())
+		case 3:
+			_, err = io.WriteString(c.Resp, ": ping\n\n")
+		}
+
+		if err != nil {
+			log.Debugf("light/web: Stream stopped, url: %s. %v", c.Req.URL.Path, err)
+			return
+		}
+		flush(c.Resp)
+	}
+}
+
+// Write the header of the event stream.
+func streamHeader(c *web.Context, status int) {
+	header := c.Resp.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	c.Resp.WriteHeader(status)
+}
+
+// The channel of the stream values, and the function to stop the iterator.
+// The iterator runs in a goroutine, its yield returns false after stopped.
+func streamSource(v reflect.Value) (reflect.Value, func()) {
+	if v.Kind() == reflect.Chan {
+		return v, func() {}
+	}
+
+	ch := make(chan interface{})
+	done := make(chan struct{})
+	yieldType := v.Type().In(0)
+	yieldTrue := reflect.ValueOf(true).Convert(yieldType.Out(0))
+	yieldFalse := reflect.ValueOf(false).Convert(yieldType.Out(0))
+
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		select {
+		case ch <- args[0].Interface():
+			return []reflect.Value{yieldTrue}
+		case <-done:
+			return []reflect.Value{yieldFalse}
+		}
+	})
+
+	go func() {
+		defer close(ch)
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorf("light/web: Stream iterator panic. %v", err)
+			}
+		}()
+		v.Call([]reflect.Value{yield})
+	}()
+	return reflect.ValueOf(ch), func() { close(done) }
+}
+
+var eventFieldReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// Write the value as an event, the Event or *Event as it is, others as the data.
+func writeEvent(w io.Writer, v interface{}) error {
+	var e Event
+	switch ev := v.(type) {
+	case Event:
+		e = ev
+	case *Event:
+		e = *ev
+	default:
+		e.Data = v
+	}
+
+	var buf bytes.Buffer
+	if e.Id != "" {
+		buf.WriteString("id: " + eventFieldReplacer.Replace(e.Id) + "\n")
+	}
+	if e.Name != "" {
+		buf.WriteString("event: " + eventFieldReplacer.Replace(e.Name) + "\n")
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry)
+	}
+
+	var data string
+	switch d := e.Data.(type) {
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"context"
+	"github.com/roverli/light/conf"
+	"github.com/roverli/light/webcore"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAppStream(t *testing.T) {
+	app := webcore.NewApp("streamApp", conf.Config{"streamHeartbeat": "0.02"})
+	app.Handle("GET/events", func(last webcore.LastEventID) <-chan webcore.Event {
+		ch := make(chan webcore.Event)
+		go func() {
+			defer close(ch)
+			ch <- webcore.Event{Id: string(last) + "1", Name: "tick", Data: "a\nb"}
+			time.Sleep(50 * time.Millisecond)
+			ch <- webcore.Event{Data: map[string]int{"n": 2}}
+		}()
+		return ch
+	})
+	app.Handle("GET/numbers", func() func(yield func(int) bool) {
+		return func(yield func(int) bool) {
+			for i := 0; ; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	})
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, req)
+
+	body := resp.Body.String()
+	if resp.Header().Get("Content-Type") != "text/event-stream" ||
+		!strings.HasPrefix(body, "id: 01\nevent: tick\ndata: a\ndata: b\n\n: ping\n\n") ||
+		!strings.HasSuffix(body, "data: {\"n\":2}\n\n") {
+		t.Errorf("unexpected stream %q.", body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp = httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.ServeHTTP(resp, httptest.NewRequest("GET", "/numbers", nil).WithContext(ctx))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+		if body := resp.Body.String(); !strings.HasPrefix(body, "data: 0\n\ndata: 1\n\n") {
+			t.Errorf("unexpected stream %q.", body)
+		}
+	case <-time.After(time.Second):
+		t.Error("stream not stopped on disconnect.")
+	}
+}
+
+func TestAppStreamNoHeartbeat(t *testing.T) {
+	app := webcore.NewApp("quietStreamApp", conf.Config{"streamHeartbeat": "0"})
+	app.Handle("GET/events", func() <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			time.Sleep(20 * time.Millisecond)
+			ch <- 1
+		}()
+		return ch
+	})
+	app.Handle("GET/none", func() <-chan int {
+		return nil
+	})
+
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/events", nil))
+	if body := resp.Body.String(); body != "data: 1\n\n" {
+		t.Errorf("unexpected stream %q.", body)
+	}
+
+	resp = httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/none", nil))
+	if resp.Code != http.StatusNoContent {
+		t.Errorf("expected 204 of nil channel, got %d.", resp.Code)
+	}
+}
+
+func TestAppEndStreams(t *testing.T) {
+	app := webcore.NewApp("endStreamsApp", nil)
+	app.Handle("GET/numbers", func(ctx context.Context) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := 0; ; i++ {
+				select {
+				case ch <- i:
+					time.Sleep(5 * time.Millisecond)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	})
+
+	// Cancelled at last, so the senders stop.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resp := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.ServeHTTP(resp, httptest.NewRequest("GET", "/numbers", nil).WithContext(ctx))
+	}()
+	time.Sleep(20 * time.Millisecond)
+	app.EndStreams()
+
+	select {
+	case <-done:
+		if body := resp.Body.String(); !strings.HasPrefix(body, "data: 0\n\n") {
+			t.Errorf("unexpected stream %q.", body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream not stopped by EndStreams.")
+	}
+
+	// The later streams end once the headers are written.
+	resp = httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("GET", "/numbers", nil).WithContext(ctx))
+	if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("unexpected response %d %v.", resp.Code, resp.Header())
+	}
+}
+
+func TestAppStreamHead(t *testing.T) {
+	app := webcore.NewApp("headStreamApp", nil)
+	var invoked int32
+	app.Handle("GET/events", func() <-chan int {
+		atomic.AddInt32(&invoked, 1)
+		ch := make(chan int)
+		go func() {
+			ch <- 1 // Blocks forever if nothing reads
+			close(ch)
+		}()
+		return ch
+	})
+
+	resp := httptest.NewRecorder()
+	app.ServeHTTP(resp, httptest.NewRequest("HEAD", "/events", nil))
+	if resp.Code != http.StatusOK || resp.Header().Get("Content-Type") != "text/event-stream" || resp.Body.Len() > 0 {
+		t.Errorf("unexpected response %d %v %q.", resp.Code, resp.Header(), resp.Body.String())
+	}
+	if n := atomic.LoadInt32(&invoked); n != 0 {
+		t.Errorf("expected the stream handler not invoked for HEAD, invoked %d.", n)
+	}
+}
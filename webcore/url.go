@@ -0,0 +1,108 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"github.com/roverli/light/bind"
+	"github.com/roverli/light/mux"
+	"github.com/roverli/light/view"
+	"github.com/roverli/utils/errors"
+	"net/url"
+	"reflect"
+)
+
+func init() {
+	view.URL = func(name string, params interface{}) (string, error) {
+		u, err := URL(name, params)
+		if err != nil {
+			return "", err
+		}
+		return u, nil
+	}
+}
+
+// URL builds the url of the named route of DefaultApp.
+func URL(name string, params interface{}) (string, errors.Error) {
+	return DefaultApp.URL(name, params)
+}
+
+// URL builds the url of the named route, the params is a map or struct.
+// The params fill the pieces of the route url, the others go to the query string.
+// Struct fields are named as binding handler args, by the "$" tag or the field name.
+func (app *App) URL(name string, params interface{}) (string, errors.Error) {
+	pattern, err := app.namedPattern(name)
+	if err != nil {
+		return "", err
+	}
+
+	path, rest, err := pattern.Build(unbindParams(params))
+	if err != nil {
+		return "", errors.Wrapf(err, "light/web: Build url of route %s error.", name)
+	}
+
+	if len(rest) == 0 {
+		return path, nil
+	}
+	query := make(url.Values)
+	for k, v := range rest {
+		query.Set(k, v)
+	}
+	return path + "?" + query.Encode(), nil
+}
+
+// The parsed url of the named route, cached in the route.
+func (app *App) namedPattern(name string) (*mux.Pattern, errors.Error) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	route, ok := app.names[name]
+	if !ok {
+		return nil, errors.Newf("light/web: No route named %s.", name)
+	}
+	if route.pattern == nil {
+		pattern, err := mux.Compile(route.Url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "light/web: Build url of route %s error.", name)
+		}
+		route.pattern = pattern
+	}
+	return route.pattern, nil
+}
+
+// Unbind the map or struct to string values by bind.Unbind.
+func unbindParams(params interface{}) map[string]string {
+	output := make(map[string]string)
+
+	v := reflect.ValueOf(params)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if val := v.MapIndex(key).Interface(); key.Kind() == reflect.String && val != nil {
+				bind.Unbind(output, key.String(), val)
+			}
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := field.Tag.Get("$")
+			switch name {
+			case "":
+				name = field.Name
+			case "-":
+				continue
+			}
+			bind.Unbind(output, name, v.Field(i).Interface())
+		}
+	}
+	return output
+}
@@ -0,0 +1,40 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore_test
+
+import (
+	"fmt"
+	"github.com/roverli/light/webcore"
+	"testing"
+)
+
+func TestAppURL(t *testing.T) {
+	app := webcore.NewApp("urlApp", nil)
+	app.Handle("GET/user/(id:^[0-9]+$)/page(num)", func() {}, webcore.Name("userPage"))
+	app.Handle("GET/users/(id)", func() {}, webcore.Name("user"))
+	app.Handle("GET/users/(id)/posts/", func() {}, webcore.Name("posts"))
+
+	u, err := app.URL("user", user{Id: 1, Name: "li"})
+	if err != nil || u != "/users/1?name=li" {
+		t.Errorf("unexpected url %s, error %v.", u, err)
+	}
+
+	u, err = app.URL("userPage", map[string]interface{}{"id": 2, "num": 3})
+	if err != nil || u != "/user/2/page3" {
+		t.Errorf("unexpected url %s, error %v.", u, err)
+	}
+
+	for _, id := range []int{1, 2} {
+		u, err = app.URL("posts", map[string]int{"id": id})
+		if expected := fmt.Sprintf("/users/%d/posts/", id); err != nil || u != expected {
+			t.Errorf("expected url %s, got %s, error %v.", expected, u, err)
+		}
+	}
+
+	if _, err = app.URL("userPage", map[string]string{"id": "x", "num": "1"}); err == nil {
+		t.Error("expected regex error.")
+	}
+	if _, err = app.URL("none", nil); err == nil {
+		t.Error("expected unknown name error.")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2014 li. All rights reserved.
+
+package webcore
+
+import (
+	"github.com/roverli/utils/slice"
+	"strings"
+)
+
+// Split the methods part of restful url, eg. "GET|POST".
+func splitMethods(str string) []string {
+	return slice.MapString(strings.Split(str, "|"), func(s string) string { return strings.TrimSpace(s) })
+}
+
+// Split the url into trimmed pieces, empty pieces are dropped.
+func splitUrl(url string) []string {
+	strs := strings.Split(url, "/")
+	pieces := make([]string, 0, len(strs))
+	for _, s := range strs {
+		if s = strings.TrimSpace(s); s != "" {
+			pieces = append(pieces, s)
+		}
+	}
+	return pieces
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}